@@ -0,0 +1,172 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//同步到磁盘的方式
+// SYNC is the policy to sync file of a file logger to disk.
+type SYNC int
+
+const (
+	// SYNC_NEVER leaves syncing to the operating system.
+	SYNC_NEVER SYNC = iota //不主动同步
+	// SYNC_INTERVAL syncs file at every SyncInterval if anything has been written.
+	SYNC_INTERVAL //定时同步
+	// SYNC_ALWAYS flushes buffer and syncs file after every message.
+	SYNC_ALWAYS //每条消息都同步
+)
+
+//同步方式的名字
+var syncNames = map[SYNC]string{
+	SYNC_NEVER:    "SYNC_NEVER",
+	SYNC_INTERVAL: "SYNC_INTERVAL",
+	SYNC_ALWAYS:   "SYNC_ALWAYS",
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so policies can be decoded
+// by name case-insensitively, e.g. "sync_always" for SYNC_ALWAYS.
+func (s *SYNC) UnmarshalText(text []byte) error {
+	for sync, name := range syncNames {
+		if strings.EqualFold(string(text), name) {
+			*s = sync
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sync policy '%s'", text)
+}
+
+//默认的刷新缓冲间隔
+const defaultFlushInterval = time.Second
+
+//初始化写缓冲和同步方式
+// initBuffer validates and sets up buffering and syncing of file.
+func (f *file) initBuffer(cfg FileConfig) error {
+	if cfg.WriteBufferSize < 0 {
+		return errors.New("write buffer size cannot be negative")
+	}
+	if cfg.Sync < SYNC_NEVER || cfg.Sync > SYNC_ALWAYS {
+		return errors.New("input sync is not one of: SYNC_NEVER, SYNC_INTERVAL or SYNC_ALWAYS")
+	}
+	if cfg.Sync == SYNC_INTERVAL && cfg.SyncInterval <= 0 {
+		return errors.New("sync interval must be positive for SYNC_INTERVAL")
+	}
+
+	f.bufferSize = cfg.WriteBufferSize
+	f.flushInterval = cfg.FlushInterval
+	if f.flushInterval <= 0 {
+		f.flushInterval = defaultFlushInterval
+	}
+	f.sync = cfg.Sync
+	f.syncInterval = cfg.SyncInterval
+	return nil
+}
+
+//设置写入文件的Writer
+// resetWriter makes writes go to current file, through the buffer if enabled.
+func (f *file) resetWriter() {
+	if f.bufferSize == 0 {
+		f.counter.w = f.file
+		return
+	}
+
+	if f.buffer == nil {
+		f.buffer = bufio.NewWriterSize(f.file, f.bufferSize)
+	} else {
+		f.buffer.Reset(f.file)
+	}
+	f.counter.w = f.buffer
+}
+
+//已经写入文件的大小
+// flushedSize returns size of current file excluding bytes still in buffer.
+func (f *file) flushedSize() int64 {
+	if f.buffer == nil {
+		return f.counter.size
+	}
+	return f.counter.size - int64(f.buffer.Buffered())
+}
+
+//把缓冲写入文件
+// flush writes buffered messages to file, and syncs file to disk if sync is true and
+// anything has been written since last sync. Buffered messages are discarded when
+// they cannot be written, so later messages are not blocked by the error.
+func (f *file) flush(sync bool) error {
+	if f.buffer != nil && f.buffer.Buffered() > 0 {
+		if err := f.buffer.Flush(); err != nil {
+			f.buffer.Reset(f.file)
+			return fmt.Errorf("Flush '%s': %v", f.filename, err)
+		}
+	}
+
+	if sync && f.unsynced {
+		f.unsynced = false
+		if err := f.file.Sync(); err != nil {
+			return fmt.Errorf("Sync '%s': %v", f.filename, err)
+		}
+	}
+	return nil
+}
+
+//写入缓冲并同步到磁盘
+// Sync writes buffered messages to file and syncs it to disk, it implements syncer.
+func (f *file) Sync() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.flush(true)
+}
+
+//定时刷新缓冲和同步
+// flushLoop flushes buffer at every flush interval and syncs file at every sync interval
+// until flushQuit is closed.
+func (f *file) flushLoop() {
+	defer f.background.Done()
+
+	var flushC, syncC <-chan time.Time
+	if f.bufferSize > 0 {
+		ticker := time.NewTicker(f.flushInterval)
+		defer ticker.Stop()
+		flushC = ticker.C
+	}
+	if f.sync == SYNC_INTERVAL {
+		ticker := time.NewTicker(f.syncInterval)
+		defer ticker.Stop()
+		syncC = ticker.C
+	}
+
+	for {
+		var sync bool
+		select {
+		case <-flushC:
+		case <-syncC:
+			sync = true
+		case <-f.flushQuit:
+			return
+		}
+
+		f.lock.Lock()
+		err := f.flush(sync)
+		f.lock.Unlock()
+		if err != nil {
+			f.reportError(nil, err)
+		}
+	}
+}
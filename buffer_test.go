@@ -0,0 +1,123 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_SYNC_UnmarshalText(t *testing.T) {
+	Convey("Decode sync policy by name", t, func() {
+		var s SYNC
+		So(s.UnmarshalText([]byte("sync_always")), ShouldBeNil)
+		So(s, ShouldEqual, SYNC_ALWAYS)
+		So(s.UnmarshalText([]byte("sometimes")), ShouldNotBeNil)
+	})
+}
+
+func Test_file_initBuffer(t *testing.T) {
+	Convey("Validate buffering and syncing", t, func() {
+		f := &file{}
+		So(f.initBuffer(FileConfig{WriteBufferSize: 4096}), ShouldBeNil)
+		So(f.flushInterval, ShouldEqual, defaultFlushInterval)
+
+		So(f.initBuffer(FileConfig{WriteBufferSize: -1}), ShouldNotBeNil)
+		So(f.initBuffer(FileConfig{Sync: SYNC_ALWAYS + 1}), ShouldNotBeNil)
+		So(f.initBuffer(FileConfig{Sync: SYNC_INTERVAL}), ShouldNotBeNil)
+		So(f.initBuffer(FileConfig{Sync: SYNC_INTERVAL, SyncInterval: time.Second}), ShouldBeNil)
+	})
+}
+
+func Test_file_Buffer(t *testing.T) {
+	Convey("Write file through buffer", t, func() {
+		os.Remove("test/buffer.log")
+		read := func() string {
+			data, err := ioutil.ReadFile("test/buffer.log")
+			So(err, ShouldBeNil)
+			return string(data)
+		}
+
+		c := NewClog()
+		So(c.New(FILE, FileConfig{
+			BufferSize:      10,
+			Filename:        "test/buffer.log",
+			WriteBufferSize: 4096,
+			FlushInterval:   time.Hour,
+		}), ShouldBeNil)
+		f := c.receivers[0].Logger.(*file)
+
+		c.Info("buffered")
+		c.Warn("buffered too")
+		// Wait for messages to be written to the buffer.
+		buffered := func() int {
+			f.lock.Lock()
+			defer f.lock.Unlock()
+			if f.counter.lines < 2 {
+				return 0
+			}
+			return f.buffer.Buffered()
+		}
+		for buffered() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		So(read(), ShouldBeEmpty)
+
+		Convey("Flush on error", func() {
+			c.Error(0, "failed")
+			for !strings.Contains(read(), "failed") {
+				time.Sleep(time.Millisecond)
+			}
+			So(read(), ShouldContainSubstring, "buffered too")
+			c.Shutdown()
+		})
+
+		Convey("Flush on destroy", func() {
+			c.Shutdown()
+			So(read(), ShouldContainSubstring, "buffered too")
+		})
+	})
+
+	Convey("Flush buffer periodically", t, func() {
+		os.Remove("test/buffer.log")
+
+		c := NewClog()
+		So(c.New(FILE, FileConfig{
+			Filename:        "test/buffer.log",
+			WriteBufferSize: 4096,
+			FlushInterval:   10 * time.Millisecond,
+			Sync:            SYNC_INTERVAL,
+			SyncInterval:    10 * time.Millisecond,
+		}), ShouldBeNil)
+		defer c.Shutdown()
+
+		c.Info("flushed by timer")
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if data, _ := ioutil.ReadFile("test/buffer.log"); strings.Contains(string(data), "flushed by timer") {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		data, err := ioutil.ReadFile("test/buffer.log")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "flushed by timer")
+	})
+}
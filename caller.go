@@ -0,0 +1,140 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+//调用位置
+// Caller represents the code location where a message is produced.
+type Caller struct {
+	File     string //文件
+	Line     int    //行号
+	Function string //函数名，不包含包名，例如 "(*Clog).Write"
+	Package  string //包的导入路径
+}
+
+//短格式的调用位置，例如 "...uban-builder/main.go:64 main()"
+// String returns short form of the code location.
+func (c *Caller) String() string {
+	file := c.File
+	if len(file) > 20 {
+		file = "..." + file[len(file)-20:]
+	}
+	return fmt.Sprintf("%s:%d %s()", file, c.Line, c.Function)
+}
+
+//拆分完整的函数名
+// splitFuncName splits full function name into package import path and function name,
+// e.g. "github.com/go-clog/clog.(*Clog).Write" to "github.com/go-clog/clog" and "(*Clog).Write".
+// Dots in last element of import path are escaped as "%2e" by the compiler, e.g. "gopkg.in/clog%2ev1".
+func splitFuncName(name string) (pkg, fn string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += slash + 1
+	return strings.Replace(name[:dot], "%2e", ".", -1), name[dot+1:]
+}
+
+//根据栈帧生成调用位置
+func newCaller(frame runtime.Frame) *Caller {
+	pkg, fn := splitFuncName(frame.Function)
+	if len(fn) == 0 {
+		fn = "?"
+	}
+	return &Caller{
+		File:     frame.File,
+		Line:     frame.Line,
+		Function: fn,
+		Package:  pkg,
+	}
+}
+
+//指定层级的调用位置
+// callerAt returns the caller at given skip, which is relative to the caller of
+// callerAt in the same way as runtime.Caller. It returns nil if there is no such frame.
+func callerAt(skip int) *Caller {
+	pcs := make([]uintptr, 1)
+	if runtime.Callers(skip+2, pcs) == 0 {
+		return nil
+	}
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	return newCaller(frame)
+}
+
+//本包的导入路径
+var pkgPath = reflect.TypeOf(Clog{}).PkgPath()
+
+//辅助函数的集合
+// helpers keeps full names of functions marked by Helper.
+var helpers = struct {
+	sync.RWMutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+//标记辅助函数
+// Helper marks the calling function as a logging helper function.
+// When locating caller automatically, helper functions are skipped
+// in the same way as testing.T.Helper.
+func Helper() {
+	pcs := make([]uintptr, 1)
+	if runtime.Callers(2, pcs) == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pcs).Next()
+
+	helpers.Lock()
+	helpers.names[frame.Function] = true
+	helpers.Unlock()
+}
+
+//是否跳过这个栈帧
+// isSkippedFrame returns true if the frame belongs to this package (excluding tests),
+// the runtime (e.g. runtime.gopanic when logging a panic) or a helper function.
+func isSkippedFrame(frame runtime.Frame) bool {
+	pkg, _ := splitFuncName(frame.Function)
+	if (pkg == pkgPath && !strings.HasSuffix(frame.File, "_test.go")) || pkg == "runtime" {
+		return true
+	}
+
+	helpers.RLock()
+	defer helpers.RUnlock()
+	return helpers.names[frame.Function]
+}
+
+//自动计算调用位置
+// autoCaller returns the first caller outside of this package, the runtime and helper functions.
+// It returns nil if there is no such frame.
+func autoCaller() *Caller {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isSkippedFrame(frame) {
+			return newCaller(frame)
+		}
+		if !more {
+			return nil
+		}
+	}
+}
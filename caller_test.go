@@ -0,0 +1,91 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_splitFuncName(t *testing.T) {
+	Convey("Split full function name", t, func() {
+		pkg, fn := splitFuncName("github.com/go-clog/clog.(*Clog).Write")
+		So(pkg, ShouldEqual, "github.com/go-clog/clog")
+		So(fn, ShouldEqual, "(*Clog).Write")
+
+		pkg, fn = splitFuncName("gopkg.in/clog%2ev1.Info")
+		So(pkg, ShouldEqual, "gopkg.in/clog.v1")
+		So(fn, ShouldEqual, "Info")
+
+		pkg, fn = splitFuncName("main.main.func1")
+		So(pkg, ShouldEqual, "main")
+		So(fn, ShouldEqual, "main.func1")
+	})
+}
+
+func Test_callerAt(t *testing.T) {
+	Convey("Locate caller with skip", t, func() {
+		caller := callerAt(0)
+		So(caller, ShouldNotBeNil)
+		So(caller.File, ShouldEndWith, "caller_test.go")
+		So(caller.Function, ShouldEqual, "Test_callerAt.func1")
+		So(caller.Package, ShouldEqual, pkgPath)
+
+		So(callerAt(1000), ShouldBeNil)
+	})
+}
+
+func logViaHelper(c *Clog) {
+	Helper()
+	c.Info("via helper")
+}
+
+func Test_autoCaller(t *testing.T) {
+	Convey("Locate caller automatically", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{
+			Caller: true,
+		}), ShouldBeNil)
+
+		buf.Reset()
+		wg.Add(1)
+		c.Info("Level: %v", INFO)
+		wg.Wait()
+		So(buf.String(), ShouldContainSubstring, "caller_test.go")
+		So(buf.String(), ShouldContainSubstring, "Test_autoCaller.func1()")
+
+		Convey("Skip helper functions", func() {
+			buf.Reset()
+			wg.Add(1)
+			logViaHelper(c)
+			wg.Wait()
+			So(buf.String(), ShouldContainSubstring, "Test_autoCaller.func1.1()")
+			So(buf.String(), ShouldNotContainSubstring, "logViaHelper")
+		})
+
+		Convey("Receivers do not ask for caller", func() {
+			So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+
+			buf.Reset()
+			wg.Add(1)
+			c.Info("Level: %v", INFO)
+			wg.Wait()
+			So(buf.String(), ShouldEqual, "[ INFO] Level: 1")
+		})
+
+		c.Shutdown()
+	})
+}
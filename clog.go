@@ -16,11 +16,11 @@
 package clog
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
-	"runtime"
 	"strings"
+	"time"
 )
 
 //版本
@@ -58,65 +58,239 @@ var formats = map[LEVEL]string{
 	FATAL: "[FATAL] ",
 }
 
+//level的名字
+var levelNames = map[LEVEL]string{
+	TRACE: "TRACE",
+	INFO:  "INFO",
+	WARN:  "WARN",
+	ERROR: "ERROR",
+	FATAL: "FATAL",
+}
+
 //是否可用
 // isValidLevel returns true if given level is in the valid range.
 func isValidLevel(level LEVEL) bool {
 	return level >= TRACE && level <= FATAL
 }
 
+//根据名字获取级别
+// ParseLevel returns the level of given name case-insensitively, e.g. "info" for INFO.
+func ParseLevel(name string) (LEVEL, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown level '%s'", name)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so levels can be decoded by name.
+func (l *LEVEL) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
 //消息的类型
 // Message represents a log message to be processed.
 type Message struct {
-	Level LEVEL  //级别
-	Body  string //内容
+	Level LEVEL //级别
+	// Time is when the message is produced.
+	Time time.Time //时间
+	Body string    //内容
+	// Module is the name of module produces the message, empty if not from a module.
+	Module string //模块
+	// Caller is the code location where message is produced, nil if not located.
+	Caller *Caller //调用位置
+	// Fields contains structured key/value pairs attached to the message.
+	Fields []Field //字段
+
+	//不为空时是Flush的标记，处理到时关闭
+	flushed chan struct{}
 }
 
+// Write sends a message to all receivers of default instance.
 func Write(level LEVEL, skip int, format string, v ...interface{}) {
+	std.write(level, skip, "", nil, format, v...)
+}
+
+// Write sends a message to all receivers whose level is not greater than given level.
+// The skip indicates how many stack frames above the caller to locate the position
+// for ERROR and FATAL messages, 0 means not to locate unless any receiver asks for
+// caller, in which case the first frame outside of clog and helpers is located.
+func (c *Clog) Write(level LEVEL, skip int, format string, v ...interface{}) {
+	c.write(level, skip, "", nil, format, v...)
+}
+
+//写日志，附带模块和字段
+// write creates a message with given module and fields, and sends it to all receivers.
+// The skip is relative to the caller of write's caller, same as Write.
+func (c *Clog) write(level LEVEL, skip int, module string, fields []Field, format string, v ...interface{}) {
+	c.lock.RLock()
+	//模块的级别大于当前日志的级别，则跳过，没有模块时使用根模块的级别
+	if minLevel, ok := c.moduleLevel(module); ok && minLevel > level {
+		c.lock.RUnlock()
+		return
+	}
+	// Send without the lock, so a blocked logger does not stall changes of receivers,
+	// and other goroutines waiting for them. The list is never modified in place.
+	receivers := c.receivers
+	c.lock.RUnlock()
+
 	//新建一个msg
 	msg := &Message{
-		Level: level,
+		Level:  level,
+		Time:   time.Now(),
+		Body:   fmt.Sprintf(format, v...),
+		Module: module,
+		Fields: fields,
 	}
+
 	// Only error and fatal information needs locate position for debugging.
 	// But if skip is 0 means caller doesn't care so we can skip.
 
 	//如果Level == ERROR且存在skip
+	autoLocated := false
 	if msg.Level >= ERROR && skip > 0 {
 		//Caller报告当前go程调用栈所执行的函数的文件和行号信息。
-		pc, file, line, ok := runtime.Caller(skip)
-		if ok {
-			// Get caller function name.
-			//返回一个表示调用栈标识符pc对应的调用栈的*Func；
-			fn := runtime.FuncForPC(pc)
-			var fnName string
-			if fn == nil {
-				fnName = "?()"
-			} else {
-				fnName = strings.TrimLeft(filepath.Ext(fn.Name()), ".") + "()"
-			}
-
-			if len(file) > 20 {
-				file = "..." + file[len(file)-20:]
+		msg.Caller = callerAt(skip + 1)
+	} else {
+		// Locate automatically when any receiver asks for caller at this level.
+		for i := range receivers {
+			if receivers[i].caller && receivers[i].minLevel() <= level {
+				msg.Caller = autoCaller()
+				autoLocated = true
+				break
 			}
-			msg.Body = formats[level] + fmt.Sprintf("[%s:%d %s] ", file, line, fnName) + fmt.Sprintf(format, v...)
 		}
 	}
-	//如果消息的body为空
-	//获取消息内容
-	if len(msg.Body) == 0 {
-		msg.Body = formats[level] + fmt.Sprintf(format, v...)
-	}
 
 	//从消息的接收者里面
 	for i := range receivers {
 		//如果消费者的level大于当前日志的级别，则跳出
-		if receivers[i].Level() > level {
+		if receivers[i].minLevel() > level {
 			continue
 		}
+
+		// Automatically located caller only goes to receivers ask for it.
+		m := msg
+		if autoLocated && !receivers[i].caller {
+			copied := *msg
+			copied.Caller = nil
+			m = &copied
+		}
 		//接收消息
-		receivers[i].msgChan <- msg
+		receivers[i].send(m)
 	}
 }
 
+//trace日志
+func (c *Clog) Trace(format string, v ...interface{}) {
+	c.Write(TRACE, 0, format, v...)
+}
+
+//info日志
+func (c *Clog) Info(format string, v ...interface{}) {
+	c.Write(INFO, 0, format, v...)
+}
+
+//warn日志
+func (c *Clog) Warn(format string, v ...interface{}) {
+	c.Write(WARN, 0, format, v...)
+}
+
+//error日志
+func (c *Clog) Error(skip int, format string, v ...interface{}) {
+	c.Write(ERROR, skip, format, v...)
+}
+
+//fatal日志
+func (c *Clog) Fatal(skip int, format string, v ...interface{}) {
+	c.Write(FATAL, skip, format, v...)
+	//关闭
+	c.Shutdown()
+	//退出
+	os.Exit(1)
+}
+
+//关闭实例
+// Shutdown destroys all receivers and stops the error handling goroutine.
+// It is safe to call multiple times, and loggers can be created again afterwards.
+func (c *Clog) Shutdown() {
+	c.ShutdownContext(context.Background())
+}
+
+//在期限内关闭实例
+// ShutdownContext destroys all receivers after they process remaining messages, and stops
+// the error handling goroutine. Receivers that are not done before ctx is done are left
+// running in background, and reported by ErrShutdown.
+func (c *Clog) ShutdownContext(ctx context.Context) error {
+	c.lock.Lock()
+	receivers := c.receivers
+	c.receivers = nil
+	c.destroying += len(receivers)
+	c.lock.Unlock()
+
+	//并行摧毁所有的消息接收者
+	done := make(chan *receiver, len(receivers))
+	for _, r := range receivers {
+		go func(r *receiver) {
+			c.release(r)
+			done <- r
+		}(r)
+	}
+	pending := make(map[*receiver]bool, len(receivers))
+	for _, r := range receivers {
+		pending[r] = true
+	}
+WAIT:
+	for len(pending) > 0 {
+		select {
+		case r := <-done:
+			delete(pending, r)
+		case <-ctx.Done():
+			break WAIT
+		}
+	}
+
+	if len(pending) > 0 {
+		// Keep error handling goroutine for receivers still running.
+		err := ErrShutdown{Err: ctx.Err()}
+		for _, r := range receivers {
+			if pending[r] {
+				err.Names = append(err.Names, r.name)
+			}
+		}
+		return err
+	}
+
+	//关闭期间创建的logger，以及之前超时仍在摧毁的logger，仍需要错误处理
+	c.lock.Lock()
+	if !c.handling || len(c.receivers) > 0 || c.destroying > 0 {
+		c.lock.Unlock()
+		return nil
+	}
+	c.handling = false
+	c.lock.Unlock()
+
+	// Shutdown the error handling goroutine without holding the lock, the error
+	// handler may log through this instance.
+	//给quitChan发送数据
+	c.quitChan <- struct{}{}
+	for {
+		//如果errorChan长度为0 退出
+		if len(c.errorChan) == 0 {
+			break
+		}
+		//处理接收到的error chan的数据
+		c.handleError(<-c.errorChan)
+	}
+	return nil
+}
+
 //trace日志
 func Trace(format string, v ...interface{}) {
 	Write(TRACE, 0, format, v...)
@@ -146,21 +320,13 @@ func Fatal(skip int, format string, v ...interface{}) {
 	os.Exit(1)
 }
 
+// Shutdown destroys all receivers of default instance and stops its error handling goroutine.
 func Shutdown() {
-	//摧毁所有的消息接收者
-	for i := range receivers {
-		receivers[i].Destroy()
-	}
+	std.Shutdown()
+}
 
-	// Shutdown the error handling goroutine.
-	//给quitChan发送数据
-	quitChan <- struct{}{}
-	for {
-		//如果errorChan长度为0 退出
-		if len(errorChan) == 0 {
-			break
-		}
-		//打印接收到的error chan的数据
-		fmt.Printf("clog: unable to write message: %v\n", <-errorChan)
-	}
+// ShutdownContext destroys all receivers of default instance and stops its error handling
+// goroutine, receivers that are not done before ctx is done are reported by ErrShutdown.
+func ShutdownContext(ctx context.Context) error {
+	return std.ShutdownContext(ctx)
 }
@@ -16,8 +16,11 @@ package clog
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"sync"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -44,11 +47,20 @@ type memoryConfig struct {
 	Level LEVEL
 	// Buffer size defines how many messages can be queued before hangs.
 	BufferSize int64
+	// Policy when buffer is full, BLOCK by default.
+	Overflow OVERFLOW
+	// Timeout of BLOCK_TIMEOUT policy, must be positive for it.
+	OverflowTimeout time.Duration
+	// Locate caller for messages of all levels.
+	Caller bool
+	// Name identifies the logger among others of same mode, defaults to the mode.
+	Name string
 }
 
 var (
-	buf bytes.Buffer
-	wg  sync.WaitGroup
+	buf     bytes.Buffer
+	bufLock sync.Mutex
+	wg      sync.WaitGroup
 )
 
 type memory struct {
@@ -75,6 +87,12 @@ func (m *memory) Init(v interface{}) error {
 		return ErrInvalidLevel{}
 	}
 	m.level = cfg.Level
+	m.caller = cfg.Caller
+	m.name = cfg.Name
+
+	if err := m.setOverflow(cfg.Overflow, cfg.OverflowTimeout); err != nil {
+		return err
+	}
 
 	m.msgChan = make(chan *Message, cfg.BufferSize)
 	return nil
@@ -86,37 +104,18 @@ func (m *memory) ExchangeChans(errorChan chan<- error) chan *Message {
 }
 
 func (m *memory) write(msg *Message) {
-	buf.WriteString(msg.Body)
+	bufLock.Lock()
+	buf.WriteString(formatBody(msg))
+	bufLock.Unlock()
 	wg.Done()
 }
 
 func (m *memory) Start() {
-LOOP:
-	for {
-		select {
-		case msg := <-m.msgChan:
-			m.write(msg)
-		case <-m.quitChan:
-			break LOOP
-		}
-	}
-
-	for {
-		if len(m.msgChan) == 0 {
-			break
-		}
-
-		m.write(<-m.msgChan)
-	}
-	m.quitChan <- struct{}{} // Notify the cleanup is done.
+	m.run(m.write)
 }
 
 func (m *memory) Destroy() {
-	m.quitChan <- struct{}{}
-	<-m.quitChan
-
-	close(m.msgChan)
-	close(m.quitChan)
+	m.stop()
 }
 
 func init() {
@@ -171,3 +170,126 @@ func Test_Clog(t *testing.T) {
 		So(buf.String(), ShouldEqual, "")
 	})
 }
+
+func Test_Clog_ShutdownContext(t *testing.T) {
+	Convey("Shut down instance with deadline", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+
+		Convey("Shut down multiple times and create logger again", func() {
+			So(c.ShutdownContext(context.Background()), ShouldBeNil)
+			So(c.receivers, ShouldBeEmpty)
+			c.Info("nowhere")
+			c.Shutdown()
+
+			So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+			buf.Reset()
+			wg.Add(1)
+			c.Info("Level: %v", INFO)
+			wg.Wait()
+			So(buf.String(), ShouldEqual, "[ INFO] Level: 1")
+			So(c.ShutdownContext(context.Background()), ShouldBeNil)
+		})
+
+		Convey("Report receivers not drained in time", func() {
+			// A logger never started cannot be destroyed.
+			hang := newMemory()
+			So(hang.Init(memoryConfig{Name: "hang"}), ShouldBeNil)
+			c.lock.Lock()
+			c.receivers = append(c.receivers, newReceiver(_MEMORY, hang, c.errorChan))
+			c.lock.Unlock()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			err := c.ShutdownContext(ctx)
+			So(err, ShouldNotBeNil)
+			e, ok := err.(ErrShutdown)
+			So(ok, ShouldBeTrue)
+			So(e.Names, ShouldResemble, []string{"hang"})
+			So(e.Err, ShouldEqual, context.DeadlineExceeded)
+
+			// Errors of the receiver still running are handled after another shutdown.
+			c.Shutdown()
+			c.lock.RLock()
+			So(c.handling, ShouldBeTrue)
+			So(c.destroying, ShouldEqual, 1)
+			c.lock.RUnlock()
+		})
+
+		Convey("Honor deadline with a writer blocked on a stuck receiver", func() {
+			c.Delete(_MEMORY)
+			stuck := &blocking{
+				Adapter: Adapter{
+					name:     "stuck",
+					msgChan:  make(chan *Message, 1),
+					quitChan: make(chan struct{}),
+				},
+				release: make(chan struct{}),
+			}
+			c.lock.Lock()
+			c.receivers = append(c.receivers, newReceiver(_MEMORY, stuck, c.errorChan))
+			c.lock.Unlock()
+			go stuck.Start()
+
+			written := make(chan struct{})
+			go func() {
+				c.Info("1")
+				c.Info("2")
+				c.Info("3")
+				close(written)
+			}()
+			for len(stuck.msgChan) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := c.ShutdownContext(ctx)
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+			_, ok := err.(ErrShutdown)
+			So(ok, ShouldBeTrue)
+			<-written
+
+			close(stuck.release)
+			c.Shutdown()
+		})
+
+		Convey("Error handler logs through the instance", func() {
+			started := make(chan struct{})
+			handled := make(chan struct{})
+			c.SetErrorHandler(func(mode MODE, err error) {
+				<-started
+				c.Info("handled: %v", err)
+				close(handled)
+			})
+			c.errorChan <- errors.New("boom")
+
+			done := make(chan struct{})
+			go func() {
+				c.Shutdown()
+				close(done)
+			}()
+			// Let the handler log once receivers are gone and shutdown is stopping it.
+			for {
+				c.lock.RLock()
+				n := len(c.receivers)
+				c.lock.RUnlock()
+				if n == 0 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond)
+			close(started)
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				So("shutdown is deadlocked", ShouldBeEmpty)
+			}
+			<-handled
+		})
+	})
+}
@@ -0,0 +1,165 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//压缩算法
+// COMPRESSION is the algorithm to compress rotated files.
+type COMPRESSION string
+
+const (
+	// GZIP compresses rotated files with gzip, the files have suffix ".gz".
+	GZIP COMPRESSION = "gzip"
+	// ZSTD compresses rotated files with zstd, the files have suffix ".zst".
+	// It is not built in, and needs to be registered by RegisterCompression.
+	ZSTD COMPRESSION = "zstd"
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler, so registered algorithms can be
+// decoded case-insensitively, e.g. "GZIP" for GZIP.
+func (c *COMPRESSION) UnmarshalText(text []byte) error {
+	for compression := range compressors {
+		if strings.EqualFold(string(text), string(compression)) {
+			*c = compression
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown compression '%s'", text)
+}
+
+//压缩算法的实现
+type compressor struct {
+	//压缩文件的后缀
+	suffix    string
+	newWriter func(io.Writer) (io.WriteCloser, error)
+}
+
+// compressors keeps registered compression algorithms.
+var compressors = map[COMPRESSION]compressor{
+	GZIP: {
+		suffix: ".gz",
+		newWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriter(w), nil
+		},
+	},
+}
+
+//注册压缩算法
+// RegisterCompression registers a compression algorithm for rotated files with the suffix
+// of compressed files, e.g. to use zstd from github.com/klauspost/compress/zstd:
+//
+//	clog.RegisterCompression(clog.ZSTD, ".zst", func(w io.Writer) (io.WriteCloser, error) {
+//		return zstd.NewWriter(w)
+//	})
+//
+// It should be called before creating loggers use the algorithm.
+func RegisterCompression(name COMPRESSION, suffix string, newWriter func(io.Writer) (io.WriteCloser, error)) {
+	if newWriter == nil {
+		panic("clog: register compression function is nil")
+	}
+	if _, ok := compressors[name]; ok {
+		panic("clog: register duplicated compression '" + name + "'")
+	}
+	compressors[name] = compressor{
+		suffix:    suffix,
+		newWriter: newWriter,
+	}
+}
+
+//压缩文件的后缀
+// compressedSuffixes returns suffixes of files compressed by all registered algorithms.
+func compressedSuffixes() []string {
+	suffixes := make([]string, 0, len(compressors))
+	for _, c := range compressors {
+		suffixes = append(suffixes, c.suffix)
+	}
+	return suffixes
+}
+
+//压缩一个文件
+// compressFile compresses the file at path into a file with the suffix of the algorithm,
+// then removes the original file. A temporary file is used so a partially compressed file
+// is never left with the final name.
+func compressFile(path string, c compressor) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + c.suffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0660)
+	if err != nil {
+		return err
+	}
+
+	w, err := c.newWriter(dst)
+	if err == nil {
+		if _, err = io.Copy(w, src); err == nil {
+			err = w.Close()
+		}
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = os.Rename(tmp, path+c.suffix); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+//在后台压缩分割出的文件并调用回调
+// finishRotated compresses rotated file if compression is enabled and then calls OnRotate
+// with final path of it, both in background. Compression errors are reported to error
+// channel, and Destroy waits for compression but not for OnRotate, which may take long.
+func (f *file) finishRotated(path string) {
+	newPath := f.filename
+	onRotate := f.rotate.OnRotate
+	if len(f.rotate.Compress) == 0 {
+		if onRotate != nil {
+			go onRotate(path, newPath)
+		}
+		return
+	}
+
+	c := compressors[f.rotate.Compress]
+	f.background.Add(1)
+	go func() {
+		err := compressFile(path, c)
+		if err != nil {
+			f.reportError(nil, fmt.Errorf("compress '%s': %v", path, err))
+		} else {
+			path += c.suffix
+		}
+		f.background.Done()
+
+		if onRotate != nil {
+			onRotate(path, newPath)
+		}
+	}()
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_RegisterCompression(t *testing.T) {
+	Convey("Register compression algorithm", t, func() {
+		Convey("Register with nil function", func() {
+			defer func() {
+				So(recover(), ShouldNotBeNil)
+			}()
+			RegisterCompression("nil", ".nil", nil)
+		})
+
+		Convey("Register duplicated algorithm", func() {
+			defer func() {
+				So(recover(), ShouldNotBeNil)
+			}()
+			RegisterCompression(GZIP, ".gz", func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(w), nil
+			})
+		})
+
+		Convey("Use unregistered algorithm", func() {
+			_, err := NewFileWriter("test/compress.log", FileRotationConfig{
+				Compress: ZSTD,
+			})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "unknown compression 'zstd'")
+		})
+	})
+}
+
+func Test_file_Compress(t *testing.T) {
+	Convey("Compress rotated files", t, func() {
+		os.RemoveAll("test/compress")
+
+		c := NewClog()
+		So(c.New(FILE, FileConfig{
+			Filename: "test/compress/compress.log",
+			FileRotationConfig: FileRotationConfig{
+				Rotate:   true,
+				MaxLines: 1,
+				Compress: GZIP,
+			},
+		}), ShouldBeNil)
+		f := c.receivers[0].Logger.(*file)
+
+		c.Info("first message")
+		c.Info("second message")
+		c.Shutdown()
+
+		files, err := filepath.Glob("test/compress/compress.log.*")
+		So(err, ShouldBeNil)
+		So(len(files), ShouldEqual, 2)
+		for _, name := range files {
+			So(name, ShouldEndWith, ".gz")
+		}
+
+		// Second rotated file has a sequence number, e.g. "compress.log.2017-03-05.001.gz".
+		first := files[1]
+		if strings.HasSuffix(first, ".001.gz") {
+			first = files[0]
+		}
+		fr, err := os.Open(first)
+		So(err, ShouldBeNil)
+		defer fr.Close()
+		gr, err := gzip.NewReader(fr)
+		So(err, ShouldBeNil)
+		data, err := ioutil.ReadAll(gr)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "first message")
+
+		Convey("Detect collision with compressed files", func() {
+			date := strings.TrimPrefix(first, "test/compress/compress.log.")
+			date = strings.TrimSuffix(date, ".gz")
+			name, err := f.rotateFilename(date)
+			So(err, ShouldBeNil)
+			So(name, ShouldEqual, "test/compress/compress.log."+date+".002")
+		})
+	})
+}
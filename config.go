@@ -0,0 +1,395 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+//从通用的map解析配置
+// ConfigDecoder is implemented by loggers which can decode their config from a generic map,
+// it is required for the mode to be used in configuration files.
+type ConfigDecoder interface {
+	// DecodeConfig returns the config struct to be passed to Init.
+	DecodeConfig(map[string]interface{}) (interface{}, error)
+}
+
+//配置项的名字，忽略大小写、下划线和连字符
+// normalizeKey returns the canonical form of option name, e.g. "buffer_size",
+// "BUFFER_SIZE" and "BufferSize" are all "buffersize".
+func normalizeKey(key string) string {
+	key = strings.Replace(key, "_", "", -1)
+	key = strings.Replace(key, "-", "", -1)
+	return strings.ToLower(key)
+}
+
+//解析配置
+// DecodeMap decodes a generic map into the struct pointed by v. Keys are matched to
+// field names ignoring case, underscores and hyphens, fields of embedded structs are
+// matched as if they were in the outer struct. Strings are accepted for types implement
+// encoding.TextUnmarshaler (e.g. LEVEL), time.Duration, booleans and numbers.
+// It is a helper for implementing ConfigDecoder.
+func DecodeMap(m map[string]interface{}, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("DecodeMap: v must be a pointer to struct")
+	}
+	return decodeStruct(m, rv.Elem())
+}
+
+//收集结构体的字段，包括嵌入的结构体
+func collectFields(v reflect.Value, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		//未导出的字段
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			collectFields(v.Field(i), fields)
+			continue
+		}
+		fields[normalizeKey(f.Name)] = v.Field(i)
+	}
+}
+
+func decodeStruct(m map[string]interface{}, v reflect.Value) error {
+	fields := make(map[string]reflect.Value)
+	collectFields(v, fields)
+
+	for key, val := range m {
+		field, ok := fields[normalizeKey(key)]
+		if !ok {
+			return fmt.Errorf("unknown option '%s'", key)
+		}
+		if err := decodeValue(val, field); err != nil {
+			return fmt.Errorf("option '%s': %v", key, err)
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//解析一个值
+func decodeValue(val interface{}, v reflect.Value) error {
+	if s, ok := val.(string); ok {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("expect string but got %T", val)
+		}
+		v.SetString(s)
+
+	case reflect.Bool:
+		switch b := val.(type) {
+		case bool:
+			v.SetBool(b)
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return err
+			}
+			v.SetBool(parsed)
+		default:
+			return fmt.Errorf("expect bool but got %T", val)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(val)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, v.Type())
+		}
+		v.SetInt(n)
+
+	case reflect.Struct:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expect table but got %T", val)
+		}
+		return decodeStruct(m, v)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+//转换为整数
+func toInt64(val interface{}) (int64, error) {
+	switch n := val.(type) {
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", n)
+		}
+		return int64(n), nil
+	case float64:
+		// JSON numbers are always float64.
+		if n != math.Trunc(n) || n > math.MaxInt64 || n < math.MinInt64 {
+			return 0, fmt.Errorf("%v is not an integer", n)
+		}
+		return int64(n), nil
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	}
+	return 0, fmt.Errorf("expect integer but got %T", val)
+}
+
+//统一map的类型，YAML的key可以不是字符串
+// normalizeValue converts maps to map[string]interface{} and lists to []interface{}
+// recursively, as YAML and TOML decoders produce different types.
+func normalizeValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = normalizeValue(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			list[i] = normalizeValue(v[i])
+		}
+		return list
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i := range v {
+			list[i] = normalizeValue(v[i])
+		}
+		return list
+	}
+	return val
+}
+
+//一个logger的配置
+// receiverConfig is the config of a logger decoded from configuration file.
+type receiverConfig struct {
+	mode MODE
+	name string
+	//配置项，key已经统一
+	options map[string]interface{}
+	//传给Init的配置
+	cfg interface{}
+}
+
+//环境变量的前缀
+// envPrefix returns prefix of environment variables override options of given logger,
+// e.g. "CLOG_FILE_" for logger named "file".
+func envPrefix(name string) string {
+	return "CLOG_" + strings.ToUpper(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)) + "_"
+}
+
+//用环境变量覆盖配置
+// applyEnv overrides options with environment variables in form of CLOG_<NAME>_<OPTION>,
+// e.g. CLOG_FILE_LEVEL=trace. Variables go to the logger with longest matching name.
+func applyEnv(configs []*receiverConfig, environ []string) {
+	for _, env := range environ {
+		i := strings.Index(env, "=")
+		if i < 0 {
+			continue
+		}
+		key, val := env[:i], env[i+1:]
+
+		var target *receiverConfig
+		var prefix string
+		for _, rc := range configs {
+			p := envPrefix(rc.name)
+			if strings.HasPrefix(key, p) && len(p) > len(prefix) {
+				target, prefix = rc, p
+			}
+		}
+		if target == nil || len(key) == len(prefix) {
+			continue
+		}
+		target.options[normalizeKey(key[len(prefix):])] = val
+	}
+}
+
+//解析配置文件
+// parseConfig parses configuration document in given format ("json", "yaml" or "toml"),
+// and decodes config of every logger listed in "receivers".
+func parseConfig(data []byte, format string) ([]*receiverConfig, error) {
+	var doc map[string]interface{}
+	var err error
+	switch format {
+	case "json":
+		err = json.Unmarshal(data, &doc)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &doc)
+	case "toml":
+		_, err = toml.Decode(string(data), &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config format '%s'", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := normalizeValue(doc["receivers"]).([]interface{})
+	if !ok {
+		return nil, errors.New("'receivers' must be a list")
+	}
+
+	configs := make([]*receiverConfig, 0, len(list))
+	names := make(map[string]bool, len(list))
+	for i := range list {
+		item, ok := list[i].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("receivers[%d]: expect table but got %T", i, list[i])
+		}
+
+		options := make(map[string]interface{}, len(item))
+		for key, val := range item {
+			options[normalizeKey(key)] = val
+		}
+		mode, _ := options["mode"].(string)
+		if len(mode) == 0 {
+			return nil, fmt.Errorf("receivers[%d]: 'mode' is required", i)
+		}
+		delete(options, "mode")
+
+		//名称默认为模式
+		name, _ := options["name"].(string)
+		if len(name) == 0 {
+			name = mode
+		}
+		if names[name] {
+			return nil, fmt.Errorf("receivers[%d]: duplicated name '%s'", i, name)
+		}
+		names[name] = true
+
+		configs = append(configs, &receiverConfig{
+			mode:    MODE(mode),
+			name:    name,
+			options: options,
+		})
+	}
+	applyEnv(configs, os.Environ())
+
+	for _, rc := range configs {
+		factory, ok := factories[rc.mode]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown mode '%s'", rc.name, rc.mode)
+		}
+		decoder, ok := factory().(ConfigDecoder)
+		if !ok {
+			return nil, fmt.Errorf("%s: mode '%s' cannot decode config", rc.name, rc.mode)
+		}
+		cfg, err := decoder.DecodeConfig(rc.options)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", rc.name, err)
+		}
+		rc.cfg = cfg
+	}
+	return configs, nil
+}
+
+//读取配置文件
+// readConfig reads and parses configuration file, format is determined by file extension.
+func readConfig(path string) ([]*receiverConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	configs, err := parseConfig(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return configs, nil
+}
+
+//加载配置文件
+// LoadConfig creates loggers listed in a JSON, YAML or TOML file, format is determined
+// by file extension (".json", ".yaml", ".yml" or ".toml"). For example in YAML:
+//
+//	receivers:
+//	  - mode: console
+//	    level: trace
+//	  - mode: file
+//	    level: info
+//	    filename: log/app.log
+//	    rotate: true
+//	    max_size: 10485760
+//
+// Options are fields of config struct of the mode. Environment variables in form of
+// CLOG_<NAME>_<OPTION> override options, e.g. CLOG_FILE_LEVEL=warn, where name is the
+// mode unless specified. All configs are decoded before any logger is created.
+func (c *Clog) LoadConfig(path string) error {
+	configs, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+
+	for _, rc := range configs {
+		if err = c.add(rc.mode, rc.cfg, rc.options); err != nil {
+			return fmt.Errorf("%s: %v", rc.name, err)
+		}
+	}
+	return nil
+}
+
+// LoadConfig creates loggers listed in configuration file for default instance.
+func LoadConfig(path string) error {
+	return std.LoadConfig(path)
+}
@@ -0,0 +1,173 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_DecodeMap(t *testing.T) {
+	Convey("Decode config from a generic map", t, func() {
+		var cfg FileConfig
+		So(DecodeMap(map[string]interface{}{
+			"level":            "warn",
+			"BufferSize":       float64(100),
+			"overflow":         "drop_oldest",
+			"overflow_timeout": "1s",
+			"caller":           "true",
+			"filename":         "test/config.log",
+			"max-size":         1024,
+			"compress":         "GZIP",
+			"encoding":         "JSON",
+			"sync":             "sync_interval",
+			"time_format": map[string]interface{}{
+				"utc":       true,
+				"precision": "millisecond",
+			},
+		}, &cfg), ShouldBeNil)
+		So(cfg.Level, ShouldEqual, WARN)
+		So(cfg.BufferSize, ShouldEqual, 100)
+		So(cfg.Overflow, ShouldEqual, DROP_OLDEST)
+		So(cfg.OverflowTimeout, ShouldEqual, time.Second)
+		So(cfg.Caller, ShouldBeTrue)
+		So(cfg.Filename, ShouldEqual, "test/config.log")
+		So(cfg.MaxSize, ShouldEqual, 1024)
+		So(cfg.Compress, ShouldEqual, GZIP)
+		So(cfg.Encoding, ShouldEqual, JSON)
+		So(cfg.Sync, ShouldEqual, SYNC_INTERVAL)
+		So(cfg.TimeFormat.UTC, ShouldBeTrue)
+		So(cfg.TimeFormat.Precision, ShouldEqual, MILLISECOND)
+
+		Convey("Unknown option", func() {
+			err := DecodeMap(map[string]interface{}{"colour": true}, &cfg)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "unknown option 'colour'")
+		})
+
+		Convey("Invalid values", func() {
+			So(DecodeMap(map[string]interface{}{"level": "verbose"}, &cfg), ShouldNotBeNil)
+			So(DecodeMap(map[string]interface{}{"buffer_size": 1.5}, &cfg), ShouldNotBeNil)
+			So(DecodeMap(map[string]interface{}{"filename": 1}, &cfg), ShouldNotBeNil)
+			So(DecodeMap(map[string]interface{}{"formatter": "json"}, &cfg), ShouldNotBeNil)
+			So(DecodeMap(map[string]interface{}{"encoding": "xml"}, &cfg), ShouldNotBeNil)
+			So(DecodeMap(map[string]interface{}{"compress": "zstd"}, &cfg), ShouldNotBeNil)
+			So(DecodeMap(map[string]interface{}{"time_format": map[string]interface{}{"precision": "hour"}}, &cfg), ShouldNotBeNil)
+		})
+
+		Convey("Not a pointer to struct", func() {
+			So(DecodeMap(nil, cfg), ShouldNotBeNil)
+		})
+	})
+}
+
+func Test_applyEnv(t *testing.T) {
+	Convey("Override options with environment variables", t, func() {
+		file := &receiverConfig{name: "file", options: map[string]interface{}{"level": "info"}}
+		fileErrors := &receiverConfig{name: "file-errors", options: map[string]interface{}{}}
+		applyEnv([]*receiverConfig{file, fileErrors}, []string{
+			"CLOG_FILE_LEVEL=trace",
+			"CLOG_FILE_ERRORS_BUFFER_SIZE=10",
+			"CLOG_FILE_=ignored",
+			"HOME=/root",
+		})
+		So(file.options, ShouldResemble, map[string]interface{}{"level": "trace"})
+		So(fileErrors.options, ShouldResemble, map[string]interface{}{"buffersize": "10"})
+	})
+}
+
+func Test_LoadConfig(t *testing.T) {
+	Convey("Load loggers from configuration file", t, func() {
+		os.MkdirAll("test", os.ModePerm)
+
+		docs := map[string]string{
+			"test/clog.json": `{
+	"receivers": [
+		{"mode": "console", "level": "trace"},
+		{"mode": "file", "name": "errors", "level": "error", "filename": "test/config.log", "rotate": true}
+	]
+}`,
+			"test/clog.yaml": `
+receivers:
+  - mode: console
+    level: trace
+  - mode: file
+    name: errors
+    level: error
+    filename: test/config.log
+    rotate: true
+`,
+			"test/clog.toml": `
+[[receivers]]
+mode = "console"
+level = "trace"
+
+[[receivers]]
+mode = "file"
+name = "errors"
+level = "error"
+filename = "test/config.log"
+rotate = true
+`,
+		}
+		for path, doc := range docs {
+			So(ioutil.WriteFile(path, []byte(doc), os.ModePerm), ShouldBeNil)
+
+			c := NewClog()
+			So(c.LoadConfig(path), ShouldBeNil)
+			So(len(c.receivers), ShouldEqual, 2)
+			So(c.receivers[0].name, ShouldEqual, "console")
+			So(c.receivers[0].minLevel(), ShouldEqual, TRACE)
+			So(c.receivers[1].name, ShouldEqual, "errors")
+			So(c.receivers[1].minLevel(), ShouldEqual, ERROR)
+			So(c.receivers[1].Logger.(*file).rotate.Rotate, ShouldBeTrue)
+			c.Shutdown()
+		}
+
+		Convey("Override with environment variables", func() {
+			os.Setenv("CLOG_ERRORS_LEVEL", "fatal")
+			defer os.Unsetenv("CLOG_ERRORS_LEVEL")
+
+			c := NewClog()
+			So(c.LoadConfig("test/clog.yaml"), ShouldBeNil)
+			So(c.receivers[1].minLevel(), ShouldEqual, FATAL)
+			c.Shutdown()
+		})
+
+		Convey("Invalid configs", func() {
+			c := NewClog()
+			_, err := parseConfig([]byte(`receivers = 1`), "toml")
+			So(err, ShouldNotBeNil)
+			_, err = parseConfig([]byte(`{"receivers": [{"level": "info"}]}`), "json")
+			So(err, ShouldNotBeNil)
+			_, err = parseConfig([]byte(`{"receivers": [{"mode": "console"}, {"mode": "console"}]}`), "json")
+			So(err, ShouldNotBeNil)
+			_, err = parseConfig([]byte(`{"receivers": [{"mode": "unknown"}]}`), "json")
+			So(err, ShouldNotBeNil)
+			_, err = parseConfig([]byte(`{"receivers": [{"mode": "memory"}]}`), "json")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "memory: mode 'memory' cannot decode config")
+			_, err = parseConfig(nil, "ini")
+			So(err, ShouldNotBeNil)
+
+			So(c.LoadConfig("test/404.json"), ShouldNotBeNil)
+			So(len(c.receivers), ShouldEqual, 0)
+		})
+	})
+}
@@ -15,7 +15,10 @@
 package clog
 
 import (
-	"log"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
 
 	//颜色处理？
 	"github.com/fatih/color"
@@ -41,21 +44,42 @@ type ConsoleConfig struct {
 	Level LEVEL //日志的级别
 	// Buffer size defines how many messages can be queued before hangs.
 	BufferSize int64 // message的buf的大小
+	// Policy when buffer is full, BLOCK by default.
+	Overflow OVERFLOW //溢出处理
+	// Timeout of BLOCK_TIMEOUT policy, must be positive for it.
+	OverflowTimeout time.Duration //溢出超时
+	// Locate caller for messages of all levels.
+	Caller bool //调用位置
+	// Name identifies the logger among others of same mode, defaults to the mode.
+	Name string //名称
+	// Encoding of output when Formatter is nil, either TEXT or JSON.
+	// Colors are disabled for JSON.
+	Encoding ENCODING //编码
+	// Time format of builtin formatters.
+	TimeFormat TimeFormat //时间格式
+	// Formatter formats messages for output, takes precedence over Encoding and TimeFormat.
+	Formatter Formatter //格式化
 }
 
 //Adapter: level, msg chan, quit chan, error chan<-
 //
 
 type console struct {
-	*log.Logger //包含自带的日志
-	Adapter     //包含Adapter
+	Adapter //包含Adapter
+
+	//输出的位置
+	out io.Writer
+	//格式化
+	formatter Formatter
+	//是否有颜色
+	colored bool
 }
 
 //新建一个console
 func newConsole() Logger {
 	return &console{
 		//直接输出到命令行
-		Logger: log.New(color.Output, "", log.Ldate|log.Ltime),
+		out: color.Output,
 		//定义退出的chan
 		Adapter: Adapter{
 			quitChan: make(chan struct{}),
@@ -79,11 +103,34 @@ func (c *console) Init(v interface{}) error {
 	}
 	//定义日志级别
 	c.level = cfg.Level
+	c.caller = cfg.Caller
+	c.name = cfg.Name
+
+	if err := c.setOverflow(cfg.Overflow, cfg.OverflowTimeout); err != nil {
+		return err
+	}
+	//格式化
+	formatter, err := newFormatter(cfg.Formatter, cfg.Encoding, cfg.TimeFormat)
+	if err != nil {
+		return err
+	}
+	c.formatter = formatter
+	//JSON不加颜色
+	_, isJSON := formatter.(*JSONFormatter)
+	c.colored = !isJSON
 	//定义chan的大小
 	c.msgChan = make(chan *Message, cfg.BufferSize)
 	return nil
 }
 
+//从map解析配置
+// DecodeConfig decodes ConsoleConfig from a generic map.
+func (c *console) DecodeConfig(m map[string]interface{}) (interface{}, error) {
+	var cfg ConsoleConfig
+	err := DecodeMap(m, &cfg)
+	return cfg, err
+}
+
 //把error chan赋值到当前的chan里面
 //返回当前的msgChan
 func (c *console) ExchangeChans(errorChan chan<- error) chan *Message {
@@ -93,45 +140,30 @@ func (c *console) ExchangeChans(errorChan chan<- error) chan *Message {
 
 //按照级别显示日志，显示日志的时候有颜色
 func (c *console) write(msg *Message) {
-	c.Logger.Print(consoleColors[msg.Level](msg.Body))
+	data, err := c.formatter.Format(msg)
+	if err != nil {
+		c.reportError(msg, fmt.Errorf("Format: %v", err))
+		return
+	}
+
+	//换行符不加颜色
+	if c.colored {
+		line := bytes.TrimSuffix(data, newLineBytes)
+		data = []byte(consoleColors[msg.Level](string(line)) + "\n")
+	}
+	if _, err = c.out.Write(data); err != nil {
+		c.reportError(msg, err)
+	}
 }
 
 //开始运行
 func (c *console) Start() {
-LOOP:
-	for {
-		//接收消息，打印消息
-		//如果接收到quit chan退出当前的loop
-		select {
-		case msg := <-c.msgChan:
-			c.write(msg)
-
-		case <-c.quitChan: //从quit读到过数据
-			break LOOP
-		}
-	}
-	//msgChan处理完了跳出
-	for {
-		if len(c.msgChan) == 0 {
-			break
-		}
-
-		c.write(<-c.msgChan)
-	}
-	//把数据发送给quitchan
-	c.quitChan <- struct{}{} // Notify the cleanup is done.
+	c.run(c.write)
 }
 
 //摧毁日志
 func (c *console) Destroy() {
-	//发送关闭，跳出接收日志的循环
-	c.quitChan <- struct{}{}
-
-	//等待处理剩余消息的完毕
-	<-c.quitChan
-	//关闭msgChan，关闭quitchan
-	close(c.msgChan)
-	close(c.quitChan)
+	c.stop()
 }
 
 //注册日志
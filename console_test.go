@@ -40,6 +40,32 @@ func Test_console_Init(t *testing.T) {
 				_, ok := err.(ErrInvalidLevel)
 				So(ok, ShouldBeTrue)
 			})
+
+			Convey("Incorrect overflow policy", func() {
+				err := New(CONSOLE, ConsoleConfig{
+					Overflow: OVERFLOW(-1),
+				})
+				So(err, ShouldNotBeNil)
+				_, ok := err.(ErrInvalidOverflow)
+				So(ok, ShouldBeTrue)
+			})
+
+			Convey("Block with timeout but no timeout", func() {
+				err := New(CONSOLE, ConsoleConfig{
+					Overflow: BLOCK_TIMEOUT,
+				})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "overflow timeout must be positive for BLOCK_TIMEOUT")
+			})
+
+			Convey("Incorrect encoding", func() {
+				err := New(CONSOLE, ConsoleConfig{
+					Encoding: "xml",
+				})
+				So(err, ShouldNotBeNil)
+				_, ok := err.(ErrInvalidEncoding)
+				So(ok, ShouldBeTrue)
+			})
 		})
 	})
 }
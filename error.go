@@ -14,7 +14,10 @@
 
 package clog
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 //错误的定义，
 type ErrConfigObject struct {
@@ -35,3 +38,52 @@ type ErrInvalidLevel struct{}
 func (err ErrInvalidLevel) Error() string {
 	return "input level is not one of: TRACE, INFO, WARN, ERROR or FATAL"
 }
+
+//不可用的编码
+type ErrInvalidEncoding struct {
+	encoding ENCODING
+}
+
+func (err ErrInvalidEncoding) Error() string {
+	return fmt.Sprintf("encoding '%s' is not one of: text or json", err.encoding)
+}
+
+//不可用的溢出处理方式
+type ErrInvalidOverflow struct{}
+
+func (err ErrInvalidOverflow) Error() string {
+	return "input overflow is not one of: BLOCK, DROP_NEWEST, DROP_OLDEST or BLOCK_TIMEOUT"
+}
+
+//写消息时的错误
+// ErrWrite is an error occurred when a logger writes a message.
+type ErrWrite struct {
+	// Mode and name of the logger where error occurred.
+	Mode MODE
+	Name string
+	// Message failed to write, nil if error is not related to a particular message.
+	Message *Message
+	// Err is the underlying error.
+	Err error
+}
+
+func (err ErrWrite) Error() string {
+	if len(err.Name) > 0 && err.Name != string(err.Mode) {
+		return fmt.Sprintf("%s(%s): %v", err.Mode, err.Name, err.Err)
+	}
+	return fmt.Sprintf("%s: %v", err.Mode, err.Err)
+}
+
+//关闭时没有处理完消息的logger
+// ErrShutdown is returned by ShutdownContext when some loggers did not process remaining
+// messages in time, they are left running in background.
+type ErrShutdown struct {
+	// Names of loggers not done.
+	Names []string
+	// Err is the error of context, e.g. context.DeadlineExceeded.
+	Err error
+}
+
+func (err ErrShutdown) Error() string {
+	return fmt.Sprintf("loggers not drained in time: %s: %v", strings.Join(err.Names, ", "), err.Err)
+}
@@ -0,0 +1,137 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//键值对
+// Field represents a typed key/value pair attached to a log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+//把交替的键值转换为字段
+// toFields converts alternating key/value arguments to fields.
+// A key without value gets nil as its value.
+func toFields(kv []interface{}) []Field {
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+
+		var val interface{}
+		if i+1 < len(kv) {
+			val = kv[i+1]
+		}
+		fields = append(fields, Field{key, val})
+	}
+	return fields
+}
+
+//把字段转换为文本，例如 " user_id=42 req=abc"
+// formatFields renders fields as text in form of ` key=value` for each field,
+// values contain spaces or quotes are quoted.
+func formatFields(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	for _, f := range fields {
+		val := fmt.Sprint(f.Value)
+		if val == "" || strings.ContainsAny(val, " \t\r\n\"=") {
+			val = strconv.Quote(val)
+		}
+		buf.WriteString(" " + f.Key + "=" + val)
+	}
+	return buf.String()
+}
+
+//带有模块和字段的日志入口
+// Entry is a module name and a set of fields to be attached to every message it logs.
+type Entry struct {
+	clog   *Clog
+	module string
+	fields []Field
+}
+
+//新建一个带字段的入口
+// WithFields returns an entry with given alternating key/value pairs,
+// e.g. WithFields("user_id", 42, "req", id).Info("login ok").
+func WithFields(kv ...interface{}) *Entry {
+	return std.WithFields(kv...)
+}
+
+// WithFields returns an entry of the instance with given alternating key/value pairs.
+func (c *Clog) WithFields(kv ...interface{}) *Entry {
+	return &Entry{
+		clog:   c,
+		fields: toFields(kv),
+	}
+}
+
+//追加字段
+// WithFields returns a new entry with given key/value pairs appended to existing fields.
+func (e *Entry) WithFields(kv ...interface{}) *Entry {
+	fields := make([]Field, 0, len(e.fields)+(len(kv)+1)/2)
+	fields = append(fields, e.fields...)
+	return &Entry{
+		clog:   e.clog,
+		module: e.module,
+		fields: append(fields, toFields(kv)...),
+	}
+}
+
+//写日志
+// Write sends a message with entry's module and fields to all receivers.
+func (e *Entry) Write(level LEVEL, skip int, format string, v ...interface{}) {
+	e.clog.write(level, skip, e.module, e.fields, format, v...)
+}
+
+//trace日志
+func (e *Entry) Trace(format string, v ...interface{}) {
+	e.Write(TRACE, 0, format, v...)
+}
+
+//info日志
+func (e *Entry) Info(format string, v ...interface{}) {
+	e.Write(INFO, 0, format, v...)
+}
+
+//warn日志
+func (e *Entry) Warn(format string, v ...interface{}) {
+	e.Write(WARN, 0, format, v...)
+}
+
+//error日志
+func (e *Entry) Error(skip int, format string, v ...interface{}) {
+	e.Write(ERROR, skip, format, v...)
+}
+
+//fatal日志
+func (e *Entry) Fatal(skip int, format string, v ...interface{}) {
+	e.Write(FATAL, skip, format, v...)
+	e.clog.Shutdown()
+	os.Exit(1)
+}
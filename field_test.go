@@ -0,0 +1,74 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_toFields(t *testing.T) {
+	Convey("Convert key/value pairs to fields", t, func() {
+		So(toFields(nil), ShouldBeEmpty)
+		So(toFields([]interface{}{"a", 1, "b", "x"}), ShouldResemble, []Field{{"a", 1}, {"b", "x"}})
+
+		Convey("Key without value", func() {
+			So(toFields([]interface{}{"a", 1, "b"}), ShouldResemble, []Field{{"a", 1}, {"b", nil}})
+		})
+
+		Convey("Non-string key", func() {
+			So(toFields([]interface{}{3, true}), ShouldResemble, []Field{{"3", true}})
+		})
+	})
+}
+
+func Test_formatFields(t *testing.T) {
+	Convey("Format fields as text", t, func() {
+		So(formatFields(nil), ShouldEqual, "")
+		So(formatFields([]Field{{"user_id", 42}, {"req", "abc"}}), ShouldEqual, " user_id=42 req=abc")
+		So(formatFields([]Field{{"msg", "hello world"}, {"empty", ""}}), ShouldEqual, ` msg="hello world" empty=""`)
+	})
+}
+
+func Test_WithFields(t *testing.T) {
+	Convey("Logging with fields", t, func() {
+		So(New(_MEMORY, memoryConfig{}), ShouldBeNil)
+
+		buf.Reset()
+		wg.Add(1)
+		WithFields("user_id", 42, "req", "abc").Info("login ok")
+		wg.Wait()
+		So(buf.String(), ShouldEqual, "[ INFO] login ok user_id=42 req=abc")
+
+		Convey("Append fields to an entry", func() {
+			e := WithFields("user_id", 42)
+			buf.Reset()
+			wg.Add(1)
+			e.WithFields("action", "logout").Warn("bye")
+			wg.Wait()
+			So(buf.String(), ShouldEqual, "[ WARN] bye user_id=42 action=logout")
+			So(e.fields, ShouldHaveLength, 1)
+		})
+
+		Convey("Locate caller of entry", func() {
+			buf.Reset()
+			wg.Add(1)
+			WithFields("k", "v").Error(2, "oops")
+			wg.Wait()
+			So(buf.String(), ShouldContainSubstring, "field_test.go")
+		})
+	})
+}
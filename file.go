@@ -15,14 +15,14 @@
 package clog
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -43,6 +43,22 @@ type FileRotationConfig struct {
 	Rotate bool //是否自增
 	// Rotate on daily basis.
 	Daily bool //是否每日自增
+	// Rotate at every interval of whole minutes up to 24 hours, e.g. time.Hour, takes
+	// precedence over Daily. Rotation time is aligned to midnight plus Offset.
+	//分文件的间隔
+	Interval time.Duration
+	// Offset of rotation time from midnight, e.g. 2 hours for daily rotation at 02:00.
+	//相对于零点的偏移
+	Offset time.Duration
+	// Time zone of rotation time and names of rotated files, e.g. "UTC", local by default.
+	//时区
+	TimeZone string
+	// Template of rotated file names in the directory of Filename, e.g. "app-{date}-{seq}.log".
+	// {date} is the rotation time, {seq} is a sequence number when there is a file with same
+	// {date} already, otherwise it is omitted with one preceding ".", "-" or "_". Without {seq},
+	// it goes before the extension. Default is "<base name of Filename>.{date}.{seq}".
+	//分割文件名的模板
+	NameTemplate string
 	// Maximum size in bytes of file for a rotation.
 	//最大长度去分文件
 	MaxSize int64
@@ -52,6 +68,29 @@ type FileRotationConfig struct {
 	// Maximum lifetime of a output file in days.
 	//最长生存时间
 	MaxDays int64
+	// Maximum number of rotated files to keep.
+	//最多保留的文件数
+	MaxBackups int64
+	// Maximum total size in bytes of rotated files to keep, current file is not counted.
+	//最多保留的文件总大小
+	MaxTotalSize int64
+	// Detect the file being truncated by external tools before each write, e.g. logrotate
+	// with copytruncate, so size and lines of current file are counted from scratch.
+	//检查文件是否被截断
+	CopyTruncate bool
+	// Compress rotated files in background, e.g. GZIP. Other algorithms than GZIP
+	// need to be registered by RegisterCompression.
+	//压缩
+	Compress COMPRESSION
+	// Path of a symbolic link always points to current file, useful when Filename
+	// contains {date}, e.g. "log/app.log" for "log/app-{date}.log".
+	//指向当前文件的链接
+	Symlink string
+	// OnRotate is called in background with path of the finished file (compressed if
+	// enabled) and path of the new current file after each rotation. Destroy does not
+	// wait for it.
+	//分文件后的回调
+	OnRotate func(oldPath, newPath string)
 }
 
 //文件的配置
@@ -62,12 +101,49 @@ type FileConfig struct {
 	// Buffer size defines how many messages can be queued before hangs.
 	//文件的buffer长度
 	BufferSize int64
-	// File name to outout messages.
+	// Policy when buffer is full, BLOCK by default.
+	//溢出处理
+	Overflow OVERFLOW
+	// Timeout of BLOCK_TIMEOUT policy, must be positive for it.
+	//溢出超时
+	OverflowTimeout time.Duration
+	// Locate caller for messages of all levels.
+	//调用位置
+	Caller bool
+	// Name identifies the logger among others of same mode, defaults to the mode.
+	//名称
+	Name string
+	// File name to outout messages. A {date} in base name (e.g. "log/app-{date}.log") is
+	// replaced by start time of current period, new file is opened for each period
+	// instead of renaming, it implies Rotate and Daily unless Interval is set.
 	//文件名字
 	Filename string
 	// Rotation related configurations.
 	//自旋的配置
 	FileRotationConfig
+	// Encoding of output when Formatter is nil, either TEXT or JSON.
+	//编码
+	Encoding ENCODING
+	// Time format of builtin formatters.
+	//时间格式
+	TimeFormat TimeFormat
+	// Formatter formats messages for output, takes precedence over Encoding and TimeFormat.
+	//格式化
+	Formatter Formatter
+	// Size in bytes of buffer for writes, messages are written to file in batches when
+	// the buffer is full, at every FlushInterval, for ERROR and FATAL messages, on rotation
+	// and on destroy. Zero disables buffering.
+	//写缓冲的大小
+	WriteBufferSize int
+	// Maximum time messages stay in buffer, 1 second by default.
+	//刷新缓冲的间隔
+	FlushInterval time.Duration
+	// Policy to sync file to disk, SYNC_NEVER by default.
+	//同步到磁盘的方式
+	Sync SYNC
+	// Interval of SYNC_INTERVAL policy.
+	//同步到磁盘的间隔
+	SyncInterval time.Duration
 }
 
 type file struct {
@@ -75,22 +151,41 @@ type file struct {
 	// Indicates whether object is been used in standalone mode.
 	standalone bool
 
-	//使用自带的日志
-	*log.Logger
 	Adapter //level, chan message,chan error,chan quite
 
+	//保护写操作和重新打开文件
+	lock sync.Mutex
+	//格式化
+	formatter Formatter
+
 	//文件句柄
 	file *os.File
 	//文件名字
 	filename string
-	//打开天数
-	openDay int
-	//当前的大小
-	currentSize int64
-	//当前的行数
-	currentLines int64
+	//带日期的文件名模板，为空时文件名是固定的
+	pattern string
+	//按时间分文件的时区和间隔
+	location *time.Location
+	interval time.Duration
+	//当前文件所在的周期
+	periodStart time.Time
+	periodEnd   time.Time
+	//统计当前文件的大小和行数
+	counter countingWriter
+	//写缓冲，为空时直接写入文件
+	buffer        *bufio.Writer
+	bufferSize    int
+	flushInterval time.Duration
+	//同步到磁盘的方式，以及是否有未同步的写入
+	sync         SYNC
+	syncInterval time.Duration
+	unsynced     bool
+	//停止定时刷新
+	flushQuit chan struct{}
 	//自旋配置
 	rotate FileRotationConfig
+	//后台压缩文件和定时刷新
+	background sync.WaitGroup
 }
 
 //新建一个文件句柄
@@ -108,6 +203,7 @@ func NewFileWriter(filename string, cfg FileRotationConfig) (io.Writer, error) {
 	//默认是独立服务
 	f := &file{
 		standalone: true,
+		formatter:  standaloneFormatter{},
 	}
 	//初始化基本的配置
 	if err := f.Init(FileConfig{
@@ -125,6 +221,37 @@ func (f *file) Level() LEVEL { return f.level }
 //日志行数的分隔符号
 var newLineBytes = []byte("\n")
 
+//统计写入的字节数和行数
+// countingWriter counts bytes and newlines actually written through it.
+type countingWriter struct {
+	w     io.Writer
+	size  int64
+	lines int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.size += int64(n)
+	w.lines += int64(bytes.Count(p[:n], newLineBytes))
+	return n, err
+}
+
+//统计行数
+// countLines counts newlines in r by reading it in chunks.
+func countLines(r io.Reader) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var lines int64
+	for {
+		n, err := r.Read(buf)
+		lines += int64(bytes.Count(buf[:n], newLineBytes))
+		if err == io.EOF {
+			return lines, nil
+		} else if err != nil {
+			return lines, err
+		}
+	}
+}
+
 //文件初始化, 把日志写入到文件
 func (f *file) initFile() (err error) {
 	//打开文件
@@ -133,8 +260,7 @@ func (f *file) initFile() (err error) {
 	if err != nil {
 		return fmt.Errorf("OpenFile '%s': %v", f.filename, err)
 	}
-	//新建一个日志处理类，基本的处理，加上日期-时间
-	f.Logger = log.New(f.file, "", log.Ldate|log.Ltime)
+	f.resetWriter()
 	return nil
 }
 
@@ -146,40 +272,27 @@ func isExist(path string) bool {
 	return err == nil || os.IsExist(err)
 }
 
-//获取rotate文件的名字
-// rotateFilename returns next available rotate filename with given date.
-func (f *file) rotateFilename(date string) string {
-	filename := fmt.Sprintf("%s.%s", f.filename, date)
-	//不存在直接返回文件名
-	if !isExist(filename) {
-		return filename
-	}
-
-	//如果存在文件，则在后面添加..
-	format := filename + ".%03d"
-	for i := 1; i < 1000; i++ {
-		filename := fmt.Sprintf(format, i)
-		if !isExist(filename) {
-			return filename
-		}
-	}
-
-	panic("too many log files for yesterday")
-}
+//统计当前文件的大小和行数
+// countFile gathers size and number of lines of current file for rotation.
+func (f *file) countFile(fi os.FileInfo) error {
+	//当前大小
+	f.counter.size = fi.Size()
+	f.counter.lines = 0
 
-/**
-删除超过时间是文件
-对一个目录下的文件执行同样的操作
-**/
-func (f *file) deleteOutdatedFiles() {
-	filepath.Walk(filepath.Dir(f.filename), func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() &&
-			info.ModTime().Before(time.Now().Add(-24*time.Hour*time.Duration(f.rotate.MaxDays))) &&
-			strings.HasPrefix(filepath.Base(path), filepath.Base(f.filename)) {
-			os.Remove(path)
+	//如果最大行，和当前行都大于0
+	// If there is any content in the file, count the number of lines.
+	if f.rotate.MaxLines > 0 && f.counter.size > 0 {
+		//逐块读取当前文件，不把整个文件读入内存
+		r, err := os.Open(f.filename)
+		if err != nil {
+			return fmt.Errorf("Open '%s': %v", f.filename, err)
 		}
-		return nil
-	})
+		defer r.Close()
+		if f.counter.lines, err = countLines(r); err != nil {
+			return fmt.Errorf("countLines '%s': %v", f.filename, err)
+		}
+	}
+	return nil
 }
 
 //分文件配置
@@ -190,49 +303,39 @@ func (f *file) initRotate() error {
 	if err != nil {
 		return fmt.Errorf("Stat: %v", err)
 	}
-	//当前大小
-	f.currentSize = fi.Size()
-
-	//如果最大行，和当前行都大于0
-	// If there is any content in the file, count the number of lines.
-	if f.rotate.MaxLines > 0 && f.currentSize > 0 {
-		//读取当前文件
-		data, err := ioutil.ReadFile(f.filename)
-		if err != nil {
-			return fmt.Errorf("ReadFile '%s': %v", f.filename, err)
-		}
-		//计算文件的行数
-		f.currentLines = int64(bytes.Count(data, newLineBytes)) + 1
+	if err = f.countFile(fi); err != nil {
+		return err
 	}
 
-	//是否按照天去分文件
-	if f.rotate.Daily {
-		//获取当前的时间
-		now := time.Now()
-		//获取当前的日期
-		f.openDay = now.Day()
-		//最后的时间
+	//是否按照时间去分文件，文件名带日期时不会有旧周期的文件
+	if f.interval > 0 && len(f.pattern) == 0 {
+		f.setPeriod(time.Now())
+		//最后写入的时间在当前周期之前
 		lastWriteTime := fi.ModTime()
-		if lastWriteTime.Year() != now.Year() ||
-			lastWriteTime.Month() != now.Month() ||
-			lastWriteTime.Day() != now.Day() {
+		if lastWriteTime.Before(f.periodStart) {
 			//关闭文件
 			if err = f.file.Close(); err != nil {
 				return fmt.Errorf("Close: %v", err)
 			}
 			//对当前文件重命名为自旋的文件名
-			if err = os.Rename(f.filename, f.rotateFilename(lastWriteTime.Format(SIMPLE_DATE_FORMAT))); err != nil {
+			lastStart, _ := f.period(lastWriteTime)
+			rotated, err := f.rotateFilename(lastStart.Format(f.timeLayout()))
+			if err != nil {
+				return fmt.Errorf("rotateFilename: %v", err)
+			}
+			if err = os.Rename(f.filename, rotated); err != nil {
 				return fmt.Errorf("Rename: %v", err)
 			}
 
 			if err = f.initFile(); err != nil {
 				return fmt.Errorf("initFile: %v", err)
 			}
+			f.finishRotated(rotated)
 		}
 	}
 
-	//MaxDays > 0 删除超过日期的文件
-	if f.rotate.MaxDays > 0 {
+	//删除过期的文件，非独立模式在Start中删除，以便报告错误
+	if f.standalone {
 		f.deleteOutdatedFiles()
 	}
 	return nil
@@ -250,16 +353,55 @@ func (f *file) Init(v interface{}) (err error) {
 		return ErrInvalidLevel{}
 	}
 	f.level = cfg.Level
+	f.caller = cfg.Caller
+	f.name = cfg.Name
+
+	if err = f.setOverflow(cfg.Overflow, cfg.OverflowTimeout); err != nil {
+		return err
+	}
+
+	if err = f.initBuffer(cfg); err != nil {
+		return err
+	}
+
+	//格式化，独立模式下已经设置过
+	if !f.standalone {
+		if f.formatter, err = newFormatter(cfg.Formatter, cfg.Encoding, cfg.TimeFormat); err != nil {
+			return err
+		}
+	}
+
+	//基本的rotate配置
+	f.rotate = cfg.FileRotationConfig
+	if err = f.initInterval(cfg.FileRotationConfig); err != nil {
+		return err
+	}
+	if len(cfg.NameTemplate) > 0 {
+		if _, err = parseNameTemplate(cfg.NameTemplate); err != nil {
+			return err
+		}
+	}
+	if len(cfg.Compress) > 0 {
+		if _, ok := compressors[cfg.Compress]; !ok {
+			return fmt.Errorf("unknown compression '%s'", cfg.Compress)
+		}
+	}
 
 	//文件基本名
 	f.filename = cfg.Filename
+	if strings.Contains(cfg.Filename, "{date}") {
+		if err = f.initPattern(cfg.Filename); err != nil {
+			return err
+		}
+	}
 	//创建文件夹，如果不存在，否则返回错误
 	os.MkdirAll(filepath.Dir(f.filename), os.ModePerm)
 	if err = f.initFile(); err != nil {
 		return fmt.Errorf("initFile: %v", err)
 	}
-	//基本的rotate配置
-	f.rotate = cfg.FileRotationConfig
+	if err = f.updateSymlink(); err != nil {
+		return fmt.Errorf("updateSymlink: %v", err)
+	}
 	//分文件的话，开启份文件配置
 	if f.rotate.Rotate {
 		f.initRotate()
@@ -271,117 +413,243 @@ func (f *file) Init(v interface{}) (err error) {
 	return nil
 }
 
+//从map解析配置
+// DecodeConfig decodes FileConfig from a generic map.
+func (f *file) DecodeConfig(m map[string]interface{}) (interface{}, error) {
+	var cfg FileConfig
+	err := DecodeMap(m, &cfg)
+	return cfg, err
+}
+
 //基本的错误处理
 func (f *file) ExchangeChans(errorChan chan<- error) chan *Message {
 	f.errorChan = errorChan
 	return f.msgChan
 }
 
+//检查文件是否被截断
+// checkTruncate resets size and lines of current file if it is smaller than recorded,
+// which means it has been truncated by external tools.
+func (f *file) checkTruncate() {
+	fi, err := f.file.Stat()
+	if err != nil || fi.Size() >= f.flushedSize() {
+		return
+	}
+	f.counter.size = fi.Size()
+	f.counter.lines = 0
+}
+
+//重新打开文件
+// Reopen closes and reopens the file between messages, so messages go to a new file
+// after external tools (e.g. logrotate) moved the current one.
+func (f *file) Reopen() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if err := f.flush(f.sync != SYNC_NEVER); err != nil {
+		f.reportError(nil, err)
+	}
+	oldInfo, _ := f.file.Stat()
+	f.file.Close()
+	if err := f.initFile(); err != nil {
+		return fmt.Errorf("initFile: %v", err)
+	}
+
+	//换成了新文件，重新统计
+	fi, err := f.file.Stat()
+	if err != nil {
+		return fmt.Errorf("Stat: %v", err)
+	}
+	if oldInfo == nil || !os.SameFile(oldInfo, fi) {
+		return f.countFile(fi)
+	}
+	return nil
+}
+
 //写日志
-func (f *file) write(msg *Message) int {
-	//打印消息体
-	f.Logger.Print(msg.Body)
+func (f *file) write(msg *Message) (int, error) {
+	//格式化消息
+	data, err := f.formatter.Format(msg)
+	if err != nil {
+		return 0, fmt.Errorf("Format: %v", err)
+	}
 
-	//消息的总长度
-	bytesWrote := len(msg.Body)
+	if f.rotate.CopyTruncate {
+		f.checkTruncate()
+	}
 
-	if !f.standalone {
-		//时间的长度
-		bytesWrote += LOG_PREFIX_LENGTH
+	//进入新的周期，先分文件，消息写入新的文件
+	if f.rotate.Rotate && f.interval > 0 && !time.Now().Before(f.periodEnd) {
+		f.rotateFile(msg, f.periodStart)
+	}
+
+	//写入文件，记录实际写入的字节数和行数
+	bytesWrote, err := f.counter.Write(data)
+	if err != nil {
+		return bytesWrote, fmt.Errorf("Write '%s': %v", f.filename, err)
+	}
+	f.unsynced = true
+
+	//错误消息立即写入文件
+	if f.sync == SYNC_ALWAYS || (f.buffer != nil && msg.Level >= ERROR) {
+		if err = f.flush(f.sync == SYNC_ALWAYS); err != nil {
+			return bytesWrote, err
+		}
 	}
 
 	//是否写入多个文件
 	if f.rotate.Rotate {
-		//记录消息的字节数
-		f.currentSize += int64(bytesWrote)
-		//记录消息行数
-		f.currentLines++ // TODO: should I care if log message itself contains new lines?
-
-		//是否需要分文件，分文件时间
-		var (
-			needsRotate = false
-			rotateDate  time.Time
-		)
-		//当前时间
-		now := time.Now()
-		//根据文件的打开日期和当前时间判断是否需要分文件
-		if f.rotate.Daily && now.Day() != f.openDay {
-			needsRotate = true
-			rotateDate = now.Add(-24 * time.Hour)
-
-			//超过长度，超过字节
-		} else if (f.rotate.MaxSize > 0 && f.currentSize >= f.rotate.MaxSize) ||
-			(f.rotate.MaxLines > 0 && f.currentLines >= f.rotate.MaxLines) {
-			needsRotate = true
-			rotateDate = now
+		//超过长度，超过字节
+		if (f.rotate.MaxSize > 0 && f.counter.size >= f.rotate.MaxSize) ||
+			(f.rotate.MaxLines > 0 && f.counter.lines >= f.rotate.MaxLines) {
+			f.rotateFile(msg, time.Now())
 		}
+	}
+	return bytesWrote, nil
+}
 
-		//需要分文件
-		if needsRotate {
-			//关闭文件
-			f.file.Close()
-			//重新命名
-			if err := os.Rename(f.filename, f.rotateFilename(rotateDate.Format(SIMPLE_DATE_FORMAT))); err != nil {
-				f.errorChan <- fmt.Errorf("fail to rename rotate file '%s': %v", f.filename, err)
-			}
-			//打开文件
-			if err := f.initFile(); err != nil {
-				f.errorChan <- fmt.Errorf("fail to init log file '%s': %v", f.filename, err)
-			}
-			//写now.Day
-			f.openDay = now.Day()
-			f.currentSize = 0
-			f.currentLines = 0
+//分文件
+// rotateFile renames current file with given time in its name and opens a new one.
+// When Filename contains {date} and the period has ended, current file is kept as is
+// and a file for the new period is opened instead.
+func (f *file) rotateFile(msg *Message, t time.Time) {
+	now := time.Now()
+	rotated := f.filename
+	if len(f.pattern) == 0 || now.Before(f.periodEnd) {
+		//带日期的文件名，用当前周期的日期
+		if len(f.pattern) > 0 {
+			t = f.periodStart
 		}
+		//获取新的文件名，失败时继续写入当前文件
+		name, err := f.rotateFilename(t.In(f.timeLocation()).Format(f.timeLayout()))
+		if err != nil {
+			f.reportError(msg, fmt.Errorf("fail to get rotate file name: %v", err))
+			return
+		}
+		rotated = name
+	}
+
+	//写入缓冲后关闭文件
+	if err := f.flush(f.sync != SYNC_NEVER); err != nil {
+		f.reportError(msg, err)
+	}
+	f.file.Close()
+	//重新命名
+	if rotated != f.filename {
+		if err := os.Rename(f.filename, rotated); err != nil {
+			f.reportError(msg, fmt.Errorf("fail to rename rotate file '%s': %v", f.filename, err))
+			rotated = ""
+		}
+	}
+	f.setPeriod(now)
+	if len(f.pattern) > 0 {
+		f.filename = f.datedFilename()
 	}
-	return bytesWrote
+	//打开文件
+	if err := f.initFile(); err != nil {
+		f.reportError(msg, fmt.Errorf("fail to init log file '%s': %v", f.filename, err))
+	}
+	if len(f.pattern) > 0 {
+		if err := f.updateSymlink(); err != nil {
+			f.reportError(msg, fmt.Errorf("fail to update symlink '%s': %v", f.rotate.Symlink, err))
+		}
+	}
+	f.counter.size = 0
+	f.counter.lines = 0
+	if len(rotated) > 0 {
+		f.finishRotated(rotated)
+	}
+	f.deleteOutdatedFiles()
 }
 
 //新建一个空的file？
 var _ io.Writer = new(file)
 
+//独立模式下的格式，只在内容前加上日期-时间
+// standaloneFormatter formats a message as time followed by its body.
+type standaloneFormatter struct{}
+
+func (standaloneFormatter) Format(msg *Message) ([]byte, error) {
+	line := msg.Time.Format(textTimeFormat) + " " + msg.Body
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		line += "\n"
+	}
+	return []byte(line), nil
+}
+
 //写日志文件
 // Write implements method of io.Writer interface.
 func (f *file) Write(p []byte) (int, error) {
-	return f.write(&Message{
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, err := f.write(&Message{
+		Time: time.Now(),
 		Body: string(p),
-	}), nil
+	}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 //开始运行文件服务
 func (f *file) Start() {
-LOOP:
-	for {
-		select {
-		case msg := <-f.msgChan:
-			f.write(msg)
-		case <-f.quitChan:
-			break LOOP
-		}
+	if f.rotate.Rotate {
+		f.lock.Lock()
+		f.deleteOutdatedFiles()
+		f.lock.Unlock()
+	}
+	//定时刷新缓冲和同步
+	if f.bufferSize > 0 || f.sync == SYNC_INTERVAL {
+		f.flushQuit = make(chan struct{})
+		f.background.Add(1)
+		go f.flushLoop()
 	}
 
-	//处理剩余的日志
-	for {
-		if len(f.msgChan) == 0 {
-			break
+	f.run(func(msg *Message) {
+		f.lock.Lock()
+		_, err := f.write(msg)
+		f.lock.Unlock()
+		if err != nil {
+			f.reportError(msg, err)
 		}
-
-		f.write(<-f.msgChan)
-	}
-	f.quitChan <- struct{}{} // Notify the cleanup is done.
+	})
 }
 
 //关闭日志
 func (f *file) Destroy() {
-	f.quitChan <- struct{}{}
-	<-f.quitChan
-
-	//关闭通道
-	close(f.msgChan)
-	close(f.quitChan)
+	f.stop()
+	if f.flushQuit != nil {
+		close(f.flushQuit)
+	}
+	//等待压缩和定时刷新完成，不等待回调
+	f.background.Wait()
 
-	//关闭文件
+	//写入缓冲后关闭文件
+	f.lock.Lock()
+	if err := f.flush(f.sync != SYNC_NEVER); err != nil {
+		f.reportError(nil, err)
+	}
 	f.file.Close()
+	f.lock.Unlock()
+}
+
+//是否被写入同一文件的logger替换
+// replacedOnFile returns true if the logger created with cfg has same name and writes
+// to the same file, Filename is compared with the pattern if it contains {date}.
+func (f *file) replacedOnFile(cfg interface{}) bool {
+	c, ok := cfg.(FileConfig)
+	if !ok || c.Name != f.name {
+		return false
+	}
+
+	filename := f.pattern
+	if len(filename) == 0 {
+		f.lock.Lock()
+		filename = f.filename
+		f.lock.Unlock()
+	}
+	return filepath.Clean(c.Filename) == filepath.Clean(filename)
 }
 
 //注册文件
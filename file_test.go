@@ -17,6 +17,7 @@ package clog
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -60,10 +61,117 @@ func Test_file_rotateFilename(t *testing.T) {
 			filename: "test/test.log",
 		}
 		os.Remove("test/test.log.2017-03-05")
-		So(f.rotateFilename("2017-03-05"), ShouldEqual, "test/test.log.2017-03-05")
+		os.Remove("test/test.log.2017-03-05.001")
+		name, err := f.rotateFilename("2017-03-05")
+		So(err, ShouldBeNil)
+		So(name, ShouldEqual, "test/test.log.2017-03-05")
 
 		// Pretend one log file already exists
 		ioutil.WriteFile("test/test.log.2017-03-05", []byte(""), os.ModePerm)
-		So(f.rotateFilename("2017-03-05"), ShouldEqual, "test/test.log.2017-03-05.001")
+		name, err = f.rotateFilename("2017-03-05")
+		So(err, ShouldBeNil)
+		So(name, ShouldEqual, "test/test.log.2017-03-05.001")
+	})
+}
+
+type upperFormatter struct{}
+
+func (upperFormatter) Format(msg *Message) ([]byte, error) {
+	return []byte(strings.ToUpper(msg.Body) + "\n"), nil
+}
+
+func Test_file_Formatter(t *testing.T) {
+	Convey("Write file with custom formatter", t, func() {
+		os.Remove("test/formatter.log")
+		c := NewClog()
+		So(c.New(FILE, FileConfig{
+			Filename:  "test/formatter.log",
+			Formatter: upperFormatter{},
+		}), ShouldBeNil)
+
+		c.Info("hello")
+		c.Shutdown()
+
+		data, err := ioutil.ReadFile("test/formatter.log")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldEqual, "HELLO\n")
+	})
+}
+
+func Test_NewFileWriter(t *testing.T) {
+	Convey("Write file in standalone mode", t, func() {
+		os.Remove("test/standalone.log")
+		w, err := NewFileWriter("test/standalone.log", FileRotationConfig{})
+		So(err, ShouldBeNil)
+
+		n, err := w.Write([]byte("hello"))
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, 5)
+
+		data, err := ioutil.ReadFile("test/standalone.log")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldEndWith, " hello\n")
+		So(string(data), ShouldNotContainSubstring, "TRACE")
+	})
+}
+
+func Test_file_JSON(t *testing.T) {
+	Convey("Write file in JSON lines", t, func() {
+		os.Remove("test/json.log")
+		c := NewClog()
+		So(c.New(FILE, FileConfig{
+			Filename: "test/json.log",
+			Encoding: JSON,
+		}), ShouldBeNil)
+
+		c.WithFields("user_id", 42).Info("hello\nworld")
+		c.Shutdown()
+
+		data, err := ioutil.ReadFile("test/json.log")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, `"level":"INFO","msg":"hello\nworld","user_id":42}`+"\n")
+	})
+}
+
+func Test_countLines(t *testing.T) {
+	Convey("Count lines without loading whole file", t, func() {
+		lines, err := countLines(strings.NewReader(""))
+		So(err, ShouldBeNil)
+		So(lines, ShouldEqual, 0)
+
+		lines, err = countLines(strings.NewReader(strings.Repeat("0123456789\n", 10000) + "partial"))
+		So(err, ShouldBeNil)
+		So(lines, ShouldEqual, 10000)
+	})
+}
+
+func Test_file_countRotation(t *testing.T) {
+	Convey("Rotate by exact size and lines", t, func() {
+		os.RemoveAll("test/count")
+		So(os.MkdirAll("test/count", os.ModePerm), ShouldBeNil)
+		So(ioutil.WriteFile("test/count/count.log", []byte("one\ntwo\n"), os.ModePerm), ShouldBeNil)
+
+		w, err := NewFileWriter("test/count/count.log", FileRotationConfig{
+			Rotate:   true,
+			MaxLines: 5,
+		})
+		So(err, ShouldBeNil)
+		f := w.(*file)
+		So(f.counter.size, ShouldEqual, 8)
+		So(f.counter.lines, ShouldEqual, 2)
+
+		n, err := w.Write([]byte("three\nfour"))
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, len("three\nfour"))
+		So(f.counter.lines, ShouldEqual, 4)
+		data, err := ioutil.ReadFile("test/count/count.log")
+		So(err, ShouldBeNil)
+		So(f.counter.size, ShouldEqual, len(data))
+
+		// Embedded newlines are counted and trigger rotation.
+		_, err = w.Write([]byte("five\nsix"))
+		So(err, ShouldBeNil)
+		So(f.counter.size, ShouldEqual, 0)
+		So(f.counter.lines, ShouldEqual, 0)
 	})
 }
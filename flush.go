@@ -0,0 +1,133 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"context"
+	"fmt"
+)
+
+//可以同步输出的logger
+// syncer is implemented by loggers buffer their output, e.g. file.
+type syncer interface {
+	// Sync writes buffered output to the underlying storage.
+	Sync() error
+}
+
+//等待中的Flush
+type pendingFlush struct {
+	r    *receiver
+	done chan struct{}
+}
+
+//等待队列中的消息处理完
+// FlushContext blocks until every receiver has processed messages queued before the call
+// and synced its output, or ctx is done. Receivers are not affected otherwise, so it can
+// be called at any time, e.g. before risky operations.
+func (c *Clog) FlushContext(ctx context.Context) error {
+	var pendings []pendingFlush
+
+	// Queue markers without the lock, as write does.
+	c.lock.RLock()
+	receivers := c.receivers
+	c.lock.RUnlock()
+
+	for _, r := range receivers {
+		//只有嵌入了Adapter的logger会处理标记
+		if _, ok := r.Logger.(adapterer); !ok {
+			continue
+		}
+		done := make(chan struct{})
+		queued, err := r.mark(ctx, done)
+		if err != nil {
+			return fmt.Errorf("flush '%s': %v", r.name, err)
+		}
+		//正在摧毁的logger会处理完剩余的消息
+		if queued {
+			pendings = append(pendings, pendingFlush{r, done})
+		}
+	}
+
+	for _, p := range pendings {
+		select {
+		case <-p.done:
+		case <-ctx.Done():
+			return fmt.Errorf("flush '%s': %v", p.r.name, ctx.Err())
+		}
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var err error
+	for _, p := range pendings {
+		s, ok := p.r.Logger.(syncer)
+		//已经删除的不需要同步
+		if !ok || !c.hasReceiver(p.r) {
+			continue
+		}
+		if e := s.Sync(); e != nil && err == nil {
+			err = fmt.Errorf("flush '%s': %v", p.r.name, e)
+		}
+	}
+	return err
+}
+
+// Flush is same as FlushContext without deadline.
+func (c *Clog) Flush() error {
+	return c.FlushContext(context.Background())
+}
+
+//发送Flush的标记
+// mark queues a flush marker which closes done when processed. It returns false
+// without error if the receiver is being destroyed.
+func (r *receiver) mark(ctx context.Context, done chan struct{}) (bool, error) {
+	r.sending.RLock()
+	defer r.sending.RUnlock()
+	if r.closed {
+		return false, nil
+	}
+
+	select {
+	case r.msgChan <- &Message{flushed: done}:
+		return true, nil
+	case <-r.closing:
+		return false, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+//是否包含给定的receiver，调用时需要持有锁
+func (c *Clog) hasReceiver(r *receiver) bool {
+	for i := range c.receivers {
+		if c.receivers[i] == r {
+			return true
+		}
+	}
+	return false
+}
+
+// FlushContext blocks until every receiver of default instance has processed messages
+// queued before the call and synced its output, or ctx is done.
+func FlushContext(ctx context.Context) error {
+	return std.FlushContext(ctx)
+}
+
+// Flush blocks until every receiver of default instance has processed messages
+// queued before the call and synced its output.
+func Flush() error {
+	return std.Flush()
+}
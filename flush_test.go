@@ -0,0 +1,74 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Flush(t *testing.T) {
+	Convey("Flush queued messages without shutting down", t, func() {
+		os.Remove("test/flush.log")
+
+		c := NewClog()
+		So(c.New(FILE, FileConfig{
+			BufferSize:      100,
+			Filename:        "test/flush.log",
+			WriteBufferSize: 4096,
+			FlushInterval:   time.Hour,
+		}), ShouldBeNil)
+		defer c.Shutdown()
+
+		for i := 0; i < 50; i++ {
+			c.Info("message %d", i)
+		}
+		So(c.Flush(), ShouldBeNil)
+
+		data, err := ioutil.ReadFile("test/flush.log")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "message 49")
+
+		// Logger still works after flush.
+		c.Info("after flush")
+		So(c.Flush(), ShouldBeNil)
+		data, err = ioutil.ReadFile("test/flush.log")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "after flush")
+	})
+
+	Convey("Flush with deadline", t, func() {
+		// The logger is never started, so nothing can be sent to it.
+		c := &Clog{
+			receivers: []*receiver{{
+				Logger:  newMemory(),
+				mode:    _MEMORY,
+				name:    string(_MEMORY),
+				msgChan: make(chan *Message),
+			}},
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := c.FlushContext(ctx)
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, "flush 'memory': context deadline exceeded")
+	})
+}
@@ -0,0 +1,236 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//格式化接口
+// Formatter is an interface to format a message to bytes for output.
+type Formatter interface {
+	// Format returns the bytes representation of given message.
+	Format(*Message) ([]byte, error)
+}
+
+//不带时间的文本格式，例如 "[ERROR] [db] [...main.go:64 main()] message key=value"
+// formatBody returns text representation of a message without time.
+func formatBody(msg *Message) string {
+	body := formats[msg.Level]
+	if len(msg.Module) > 0 {
+		body += "[" + msg.Module + "] "
+	}
+	if msg.Caller != nil {
+		body += "[" + msg.Caller.String() + "] "
+	}
+	return body + msg.Body + formatFields(msg.Fields)
+}
+
+//时间的精度
+// PRECISION is the precision of fractional seconds in rendered time.
+type PRECISION int
+
+const (
+	SECOND      PRECISION = iota //秒
+	MILLISECOND                  //毫秒
+	MICROSECOND                  //微秒
+)
+
+//精度的名字
+var precisionNames = map[PRECISION]string{
+	SECOND:      "SECOND",
+	MILLISECOND: "MILLISECOND",
+	MICROSECOND: "MICROSECOND",
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so precisions can be decoded
+// by name case-insensitively, e.g. "millisecond" for MILLISECOND.
+func (p *PRECISION) UnmarshalText(text []byte) error {
+	for precision, name := range precisionNames {
+		if strings.EqualFold(string(text), name) {
+			*p = precision
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown precision '%s'", text)
+}
+
+//精度对应的格式
+var precisionLayouts = map[PRECISION]string{
+	SECOND:      "",
+	MILLISECOND: ".000",
+	MICROSECOND: ".000000",
+}
+
+//时间的格式
+// TimeFormat represents how time of messages is rendered by builtin formatters.
+type TimeFormat struct {
+	// Layout as accepted by time.Format, formatter's default layout is used when empty.
+	// Precision is ignored when layout is set.
+	Layout string
+	// Precision of fractional seconds in default layout.
+	Precision PRECISION
+	// Render time in UTC instead of local time zone.
+	UTC bool
+}
+
+//格式化时间，datetime和zone组成默认的格式
+// format renders t with custom layout if any, otherwise with default layout made of
+// datetime, fractional seconds and zone.
+func (tf TimeFormat) format(t time.Time, datetime, zone string) string {
+	if tf.UTC {
+		t = t.UTC()
+	}
+	layout := tf.Layout
+	if len(layout) == 0 {
+		layout = datetime + precisionLayouts[tf.Precision] + zone
+	}
+	return t.Format(layout)
+}
+
+//默认的文本格式
+// TextFormatter formats a message as a human readable line, e.g.
+// "2017/02/06 21:20:08 [ INFO] message key=value".
+type TextFormatter struct {
+	TimeFormat
+}
+
+//日期和时间的格式
+const textTimeFormat = "2006/01/02 15:04:05"
+
+// Format implements method of Formatter interface.
+func (f *TextFormatter) Format(msg *Message) ([]byte, error) {
+	line := f.format(msg.Time, textTimeFormat, "") + " " + formatBody(msg)
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		line += "\n"
+	}
+	return []byte(line), nil
+}
+
+//默认的格式
+// DefaultFormatter is used by adapters when no formatter is specified.
+var DefaultFormatter Formatter = &TextFormatter{}
+
+//JSON格式，每行一个对象
+// JSONFormatter formats a message as a single line JSON object, e.g.
+// {"time":"2017-02-06T21:20:08+08:00","level":"INFO","msg":"message","key":"value"}.
+// Fields whose keys conflict with builtin keys are prefixed with "fields.".
+type JSONFormatter struct {
+	TimeFormat
+}
+
+//内置的键
+var jsonBuiltinKeys = map[string]bool{
+	"time":   true,
+	"level":  true,
+	"msg":    true,
+	"module": true,
+	"caller": true,
+}
+
+//写入一个键值对
+func writeJSONPair(buf *bytes.Buffer, key string, val interface{}) {
+	// Errors are marshaled as "{}" by default, which is useless.
+	if err, ok := val.(error); ok {
+		val = err.Error()
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(val))
+	}
+	k, _ := json.Marshal(key)
+
+	buf.WriteByte(',')
+	buf.Write(k)
+	buf.WriteByte(':')
+	buf.Write(data)
+}
+
+// Format implements method of Formatter interface.
+func (f *JSONFormatter) Format(msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"time":`)
+	data, err := json.Marshal(f.format(msg.Time, "2006-01-02T15:04:05", "Z07:00"))
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(data)
+
+	writeJSONPair(&buf, "level", levelNames[msg.Level])
+	writeJSONPair(&buf, "msg", msg.Body)
+	if len(msg.Module) > 0 {
+		writeJSONPair(&buf, "module", msg.Module)
+	}
+	if msg.Caller != nil {
+		writeJSONPair(&buf, "caller", fmt.Sprintf("%s:%d", msg.Caller.File, msg.Caller.Line))
+	}
+	for _, field := range msg.Fields {
+		key := field.Key
+		if jsonBuiltinKeys[key] {
+			key = "fields." + key
+		}
+		writeJSONPair(&buf, key, field.Value)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+//输出的编码
+// ENCODING is the output encoding of builtin formatters.
+type ENCODING string
+
+const (
+	TEXT ENCODING = "text"
+	JSON ENCODING = "json"
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler, so encodings can be decoded
+// case-insensitively, e.g. "JSON" for JSON.
+func (e *ENCODING) UnmarshalText(text []byte) error {
+	for _, encoding := range []ENCODING{TEXT, JSON} {
+		if strings.EqualFold(string(text), string(encoding)) {
+			*e = encoding
+			return nil
+		}
+	}
+	return ErrInvalidEncoding{ENCODING(text)}
+}
+
+//根据编码选择格式化
+// newFormatter returns given formatter if not nil, otherwise a builtin formatter
+// for given encoding and time format. DefaultFormatter is returned when nothing is specified.
+func newFormatter(formatter Formatter, encoding ENCODING, tf TimeFormat) (Formatter, error) {
+	if formatter != nil {
+		return formatter, nil
+	}
+
+	switch encoding {
+	case "":
+		if tf == (TimeFormat{}) {
+			return DefaultFormatter, nil
+		}
+		return &TextFormatter{tf}, nil
+	case TEXT:
+		return &TextFormatter{tf}, nil
+	case JSON:
+		return &JSONFormatter{tf}, nil
+	}
+	return nil, ErrInvalidEncoding{encoding}
+}
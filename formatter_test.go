@@ -0,0 +1,145 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Caller_String(t *testing.T) {
+	Convey("Short form of caller", t, func() {
+		So((&Caller{
+			File:     "main.go",
+			Line:     64,
+			Function: "main",
+		}).String(), ShouldEqual, "main.go:64 main()")
+		So((&Caller{
+			File:     "/home/unknwon/go/src/bitbucket.org/unknwon/kuban-builder/main.go",
+			Line:     64,
+			Function: "main",
+		}).String(), ShouldEqual, "...uban-builder/main.go:64 main()")
+	})
+}
+
+func Test_TextFormatter(t *testing.T) {
+	Convey("Format message as text", t, func() {
+		f := &TextFormatter{}
+		data, err := f.Format(&Message{
+			Level:  WARN,
+			Time:   time.Now(),
+			Body:   "test message",
+			Caller: &Caller{File: "main.go", Line: 1, Function: "main"},
+			Fields: []Field{{"k", "v"}},
+		})
+		So(err, ShouldBeNil)
+		So(regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[ WARN\] \[main.go:1 main\(\)\] test message k=v\n$`).Match(data), ShouldBeTrue)
+
+		Convey("Do not duplicate trailing new line", func() {
+			data, err := f.Format(&Message{
+				Level: INFO,
+				Body:  "test message\n",
+			})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEndWith, "[ INFO] test message\n")
+		})
+	})
+}
+
+func Test_JSONFormatter(t *testing.T) {
+	Convey("Format message as JSON", t, func() {
+		f := &JSONFormatter{}
+		data, err := f.Format(&Message{
+			Level:  ERROR,
+			Time:   time.Now(),
+			Body:   "line1\nline2\t\x01\"quoted\"",
+			Caller: &Caller{File: "main.go", Line: 1, Function: "main"},
+			Fields: []Field{
+				{"user_id", 42},
+				{"err", errors.New("boom")},
+				{"msg", "conflict"},
+			},
+		})
+		So(err, ShouldBeNil)
+		So(bytes.Count(data, []byte("\n")), ShouldEqual, 1)
+		So(string(data), ShouldEndWith, "}\n")
+
+		var v map[string]interface{}
+		So(json.Unmarshal(data, &v), ShouldBeNil)
+		So(v["level"], ShouldEqual, "ERROR")
+		So(v["msg"], ShouldEqual, "line1\nline2\t\x01\"quoted\"")
+		So(v["caller"], ShouldEqual, "main.go:1")
+		So(v["user_id"], ShouldEqual, 42)
+		So(v["err"], ShouldEqual, "boom")
+		So(v["fields.msg"], ShouldEqual, "conflict")
+		So(v["time"], ShouldNotBeEmpty)
+	})
+}
+
+func Test_newFormatter(t *testing.T) {
+	Convey("Select formatter by encoding", t, func() {
+		f, err := newFormatter(nil, "", TimeFormat{})
+		So(err, ShouldBeNil)
+		So(f, ShouldEqual, DefaultFormatter)
+
+		f, err = newFormatter(nil, "", TimeFormat{UTC: true})
+		So(err, ShouldBeNil)
+		_, ok := f.(*TextFormatter)
+		So(ok, ShouldBeTrue)
+
+		f, err = newFormatter(nil, JSON, TimeFormat{UTC: true})
+		So(err, ShouldBeNil)
+		jf, ok := f.(*JSONFormatter)
+		So(ok, ShouldBeTrue)
+		So(jf.UTC, ShouldBeTrue)
+
+		f, err = newFormatter(&TextFormatter{}, JSON, TimeFormat{})
+		So(err, ShouldBeNil)
+		_, ok = f.(*TextFormatter)
+		So(ok, ShouldBeTrue)
+
+		_, err = newFormatter(nil, "xml", TimeFormat{})
+		So(err, ShouldNotBeNil)
+		_, ok = err.(ErrInvalidEncoding)
+		So(ok, ShouldBeTrue)
+	})
+}
+
+func Test_TimeFormat(t *testing.T) {
+	Convey("Format time of messages", t, func() {
+		tm := time.Date(2017, 2, 6, 21, 20, 8, 123456789, time.FixedZone("CST", 8*3600))
+
+		So(TimeFormat{}.format(tm, textTimeFormat, ""), ShouldEqual, "2017/02/06 21:20:08")
+		So(TimeFormat{Precision: MILLISECOND}.format(tm, textTimeFormat, ""), ShouldEqual, "2017/02/06 21:20:08.123")
+		So(TimeFormat{Precision: MICROSECOND, UTC: true}.format(tm, "2006-01-02T15:04:05", "Z07:00"), ShouldEqual, "2017-02-06T13:20:08.123456Z")
+		So(TimeFormat{Layout: time.Kitchen, Precision: MILLISECOND}.format(tm, textTimeFormat, ""), ShouldEqual, "9:20PM")
+
+		Convey("Render time captured in message", func() {
+			data, err := (&JSONFormatter{TimeFormat{Precision: MILLISECOND}}).Format(&Message{
+				Level: INFO,
+				Time:  tm,
+				Body:  "test message",
+			})
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, `{"time":"2017-02-06T21:20:08.123+08:00","level":"INFO","msg":"test message"}`+"\n")
+		})
+	})
+}
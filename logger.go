@@ -14,7 +14,12 @@
 
 package clog
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 //日志的接口
 // Logger is an interface for a logger adapter with specific mode and level.
@@ -47,6 +52,94 @@ type Adapter struct {
 	msgChan   chan *Message //Message消息chan
 	quitChan  chan struct{} //退出的chan
 	errorChan chan<- error  //接收数据的chan
+	caller    bool          //是否记录所有级别的调用位置
+	name      string        //名称，默认为模式
+	//缓冲区满时的处理方式
+	overflow        OVERFLOW
+	overflowTimeout time.Duration
+	//统计，由clog设置
+	counters *counters
+}
+
+//处理一个消息并记录统计
+// handle passes message to write and records statistics if any, flush markers are
+// acknowledged instead of being written.
+func (a *Adapter) handle(write func(*Message), msg *Message) {
+	//Flush的标记，之前的消息都已处理
+	if msg.flushed != nil {
+		close(msg.flushed)
+		return
+	}
+	if a.counters == nil {
+		write(msg)
+		return
+	}
+
+	start := time.Now()
+	write(msg)
+	a.counters.processed(msg.Level, time.Since(start))
+}
+
+//开始运行
+// run processes messages from msgChan with write until stop is called,
+// remaining messages in msgChan are processed before it returns.
+// It is a helper for implementing Start method.
+func (a *Adapter) run(write func(*Message)) {
+LOOP:
+	for {
+		//接收消息，打印消息
+		//如果接收到quit chan退出当前的loop
+		select {
+		case msg := <-a.msgChan:
+			a.handle(write, msg)
+
+		case <-a.quitChan: //从quit读到过数据
+			break LOOP
+		}
+	}
+	//msgChan处理完了跳出
+	for {
+		if len(a.msgChan) == 0 {
+			break
+		}
+
+		a.handle(write, <-a.msgChan)
+	}
+	//把数据发送给quitchan
+	a.quitChan <- struct{}{} // Notify the cleanup is done.
+}
+
+//停止运行
+// stop notifies run to return and waits for remaining messages to be processed,
+// then closes channels. It is a helper for implementing Destroy method.
+func (a *Adapter) stop() {
+	//发送关闭，跳出接收日志的循环
+	a.quitChan <- struct{}{}
+
+	//等待处理剩余消息的完毕
+	<-a.quitChan
+	//关闭msgChan，关闭quitchan
+	close(a.msgChan)
+	close(a.quitChan)
+}
+
+//报告错误
+// reportError sends an error occurred while writing given message to error channel.
+// Errors are discarded when there is no error channel, e.g. in standalone mode.
+func (a *Adapter) reportError(msg *Message, err error) {
+	if a.errorChan == nil {
+		return
+	}
+	a.errorChan <- ErrWrite{Message: msg, Err: err}
+}
+
+//返回Adapter本身
+// adapter returns the embedded Adapter, so clog can read common settings of any logger embeds it.
+func (a *Adapter) adapter() *Adapter { return a }
+
+//嵌入了Adapter的logger
+type adapterer interface {
+	adapter() *Adapter
 }
 
 /**
@@ -75,113 +168,498 @@ func Register(mode MODE, f Factory) {
 }
 
 type receiver struct {
+	// Minimum level of messages to be sent, accessed atomically.
+	level int32 //级别
+
 	Logger  //日志接口
 	mode    MODE
+	name    string        //名称，默认为模式
 	msgChan chan *Message //消息chan
+	//这个logger的错误chan，转发到实例的错误chan
+	errorChan chan error
+	errorDone chan struct{}
+	caller    bool //是否记录调用位置
+	//缓冲区满时的处理方式
+	overflow        OVERFLOW
+	overflowTimeout time.Duration
+	//统计
+	counters *counters
+	//来自配置文件的配置项，代码创建的为nil
+	options map[string]interface{}
+	//发送消息时持有读锁，摧毁时等待发送完再关闭chan
+	sending sync.RWMutex
+	//摧毁时关闭，中止阻塞的发送
+	closing chan struct{}
+	//已经摧毁，由sending保护
+	closed bool
 }
 
-//定义两个chan
-//1 errorChan	2 quitChan
-var (
+//新建一个消息处理器，读取Adapter中的通用配置
+// newReceiver wraps an initialized logger as a receiver, errors reported by the logger
+// are counted and forwarded to errorChan as ErrWrite until the receiver is destroyed.
+func newReceiver(mode MODE, logger Logger, errorChan chan<- error) *receiver {
+	r := &receiver{
+		level:     int32(logger.Level()),
+		Logger:    logger,
+		mode:      mode,
+		name:      string(mode),
+		errorChan: make(chan error),
+		errorDone: make(chan struct{}),
+		counters:  new(counters),
+		closing:   make(chan struct{}),
+	}
+	//接收errorChan，返回一个消息chan
+	r.msgChan = logger.ExchangeChans(r.errorChan)
+	if a, ok := logger.(adapterer); ok {
+		r.caller = a.adapter().caller
+		r.overflow = a.adapter().overflow
+		r.overflowTimeout = a.adapter().overflowTimeout
+		if len(a.adapter().name) > 0 {
+			r.name = a.adapter().name
+		}
+		a.adapter().counters = r.counters
+	}
+
+	go func() {
+		for err := range r.errorChan {
+			atomic.AddInt64(&r.counters.errors, 1)
+			if e, ok := err.(ErrWrite); ok {
+				e.Mode = r.mode
+				e.Name = r.name
+				err = e
+			} else {
+				err = ErrWrite{Mode: r.mode, Name: r.name, Err: err}
+			}
+			errorChan <- err
+		}
+		close(r.errorDone)
+	}()
+	return r
+}
 
+//最低级别
+// minLevel returns minimum level of messages to be sent to the logger.
+func (r *receiver) minLevel() LEVEL {
+	return LEVEL(atomic.LoadInt32(&r.level))
+}
+
+//摧毁消息处理器
+// destroy stops sending to the logger, releases the logger and waits for its errors
+// to be forwarded. Messages being sent are discarded once it is called.
+func (r *receiver) destroy() {
+	close(r.closing)
+	r.sending.Lock()
+	r.closed = true
+	r.sending.Unlock()
+
+	r.Destroy()
+	close(r.errorChan)
+	<-r.errorDone
+}
+
+//日志实例，每个实例有自己的receivers和错误处理
+// Clog is a logger instance which has its own list of receivers and error handling.
+// Package-level functions operate on a default instance.
+type Clog struct {
+	//保护receivers
+	lock sync.RWMutex
 	//接收多个消息的receivers
 	// receivers is a list of loggers with
 	//their message channel for broadcasting.
+	//修改时替换而不是原地修改，以便在锁外发送消息
 	receivers []*receiver
+	//模块的最低级别
+	modules map[string]LEVEL
 	//错误chan
-	errorChan = make(chan error, 5)
+	errorChan chan error
 	//退出的chan
-	quitChan = make(chan struct{})
-)
+	quitChan chan struct{}
+	//错误处理的协程是否在运行，由lock保护
+	handling bool
+	//已经移除但还在摧毁中的receiver数量，由lock保护
+	destroying int
+
+	//保证重新加载配置依次进行
+	reloadLock sync.Mutex
+
+	//保护错误处理的配置
+	errorLock sync.Mutex
+	//错误处理函数
+	errorHandler func(MODE, error)
+	//接收错误的logger的名称，以及对应的消息处理器
+	fallbackName MODE
+	fallback     *receiver
+}
+
+//新建一个日志实例
+// NewClog creates and returns a new logger instance without any receiver.
+func NewClog() *Clog {
+	c := &Clog{
+		modules:      make(map[string]LEVEL),
+		errorChan:    make(chan error, 5),
+		quitChan:     make(chan struct{}),
+		errorHandler: defaultErrorHandler,
+	}
+	c.startErrorHandling()
+	return c
+}
+
+//启动错误处理的协程
+// startErrorHandling starts background error handling goroutine, which runs until
+// Shutdown. The lock must be held unless c is being created.
+func (c *Clog) startErrorHandling() {
+	c.handling = true
 
-//出事
-func init() {
-	// Start background error handling goroutine.
 	//启动一个协成，用来监控errorChan
 	//如果发生errorChan，调用quitChan
-	//发生错误一直打印，如果出现错误就跳出
+	//发生错误一直处理，如果出现错误就跳出
 	go func() {
 		for {
 			select {
-			case err := <-errorChan:
-				fmt.Printf("clog: unable to write message: %v\n", err)
-			case <-quitChan:
+			case err := <-c.errorChan:
+				c.handleError(err)
+			case <-c.quitChan:
 				return
 			}
 		}
 	}()
 }
 
+//错误的描述
+func errorText(err error) string {
+	if _, ok := err.(ErrWrite); ok {
+		return fmt.Sprintf("clog: unable to write message: %v", err)
+	}
+	return fmt.Sprintf("clog: %v", err)
+}
+
+//默认的错误处理，打印错误
+func defaultErrorHandler(mode MODE, err error) {
+	fmt.Println(errorText(err))
+}
+
+//处理错误
+// handleError sends the error to fallback logger if there is one and the error
+// does not come from itself, otherwise passes it to error handler.
+func (c *Clog) handleError(err error) {
+	var mode MODE
+	var name string
+	if e, ok := err.(ErrWrite); ok {
+		mode = e.Mode
+		name = e.Name
+	}
+
+	c.errorLock.Lock()
+	handler := c.errorHandler
+	if c.fallback != nil && c.fallback.name != name {
+		// Never block on fallback logger, it may be waiting for its own errors to be handled.
+		select {
+		case c.fallback.msgChan <- &Message{
+			Level: ERROR,
+			Time:  time.Now(),
+			Body:  errorText(err),
+		}:
+			c.errorLock.Unlock()
+			return
+		default:
+		}
+	}
+	c.errorLock.Unlock()
+
+	handler(mode, err)
+}
+
+//设置错误处理函数
+// SetErrorHandler sets the function to handle errors reported by loggers,
+// with mode of the logger where error occurred. Name of the logger is available
+// in ErrWrite. Errors are printed to stdout by default, nil handler restores the
+// default behavior.
+func (c *Clog) SetErrorHandler(handler func(mode MODE, err error)) {
+	if handler == nil {
+		handler = defaultErrorHandler
+	}
+
+	c.errorLock.Lock()
+	c.errorHandler = handler
+	c.errorLock.Unlock()
+}
+
+// SetErrorHandler sets the function to handle errors reported by loggers of default instance.
+func SetErrorHandler(handler func(mode MODE, err error)) {
+	std.SetErrorHandler(handler)
+}
+
+//设置接收错误的logger
+// SetErrorReceiver routes errors reported by other loggers to the logger of given name
+// as ERROR messages, including the logger created later with same name. Errors of the
+// logger itself, and errors when its buffer is full, go to error handler.
+// Empty name disables routing.
+func (c *Clog) SetErrorReceiver(name MODE) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	c.errorLock.Lock()
+	defer c.errorLock.Unlock()
+
+	c.fallbackName = name
+	c.fallback = nil
+	if len(name) == 0 {
+		return
+	}
+	if i := c.findReceiver(name); i >= 0 {
+		c.fallback = c.receivers[i]
+	}
+}
+
+// SetErrorReceiver routes errors reported by other loggers of default instance to the logger of given name.
+func SetErrorReceiver(name MODE) {
+	std.SetErrorReceiver(name)
+}
+
+//添加消息处理器后，更新接收错误的logger
+// bindFallback sets r as fallback logger if it has the designated name.
+func (c *Clog) bindFallback(r *receiver) {
+	c.errorLock.Lock()
+	if len(c.fallbackName) > 0 && string(c.fallbackName) == r.name {
+		c.fallback = r
+	}
+	c.errorLock.Unlock()
+}
+
+//摧毁消息处理器
+// destroyReceiver stops using r as fallback logger and destroys it.
+func (c *Clog) destroyReceiver(r *receiver) {
+	c.errorLock.Lock()
+	if c.fallback == r {
+		c.fallback = nil
+	}
+	c.errorLock.Unlock()
+
+	r.destroy()
+}
+
+//摧毁已经移除的消息处理器
+// release destroys r which has been removed from receivers with destroying counted,
+// error handling keeps running until it is done. The lock must not be held.
+func (c *Clog) release(r *receiver) {
+	c.destroyReceiver(r)
+
+	c.lock.Lock()
+	c.destroying--
+	c.lock.Unlock()
+}
+
+//按名称查找消息处理器
+// findReceiver returns index of the receiver with given name, or -1 if not found.
+// The lock must be held.
+func (c *Clog) findReceiver(name MODE) int {
+	for i := range c.receivers {
+		if c.receivers[i].name == string(name) {
+			return i
+		}
+	}
+	return -1
+}
+
+//默认的日志实例
+// std is the default instance used by package-level functions.
+var std = NewClog()
+
 //把logger和msg注册到receivers中
 
 // New initializes and appends a new logger to the receiver list.
-// Calling this function multiple times will overwrite previous logger with same mode.
-func New(mode MODE, cfg interface{}) error {
+// Loggers are identified by name, which is the mode unless Name is set in config,
+// so multiple loggers of same mode can be created with different names.
+// Calling this method multiple times will overwrite previous logger with same name.
+func (c *Clog) New(mode MODE, cfg interface{}) error {
+	return c.add(mode, cfg, nil)
+}
+
+//添加logger，记录来自配置文件的配置项
+// add creates a logger as New does, options are kept if it is created from configuration file.
+// The logger being replaced is released before the new one is initialized if both write to
+// the same file, it is gone even if initialization fails.
+func (c *Clog) add(mode MODE, cfg interface{}, options map[string]interface{}) error {
+	// Never let two loggers write to or rotate the same file at the same time.
+	if r := c.takeSameFile(cfg); r != nil {
+		c.release(r)
+	}
+
+	logger, err := newLogger(mode, cfg)
+	if err != nil {
+		return err
+	}
+	r := newReceiver(mode, logger, c.errorChan)
+	r.options = options
+
+	//是否前一个logger
+	// Release previous logger without blocking other loggers while it drains.
+	if previous := c.install(r); previous != nil {
+		c.release(previous)
+	}
+	return nil
+}
+
+//新建并初始化logger
+// newLogger creates a logger of given mode and initializes it with cfg.
+func newLogger(mode MODE, cfg interface{}) (Logger, error) {
 	//获取一种消息
 	factory, ok := factories[mode]
 	if !ok {
-		return fmt.Errorf("unknown mode '%s'", mode)
+		return nil, fmt.Errorf("unknown mode '%s'", mode)
 	}
 
 	//得到一个logger factory
 	logger := factory()
 	//初始化消息
 	if err := logger.Init(cfg); err != nil {
-		return err
+		return nil, err
 	}
-	//接收errorChan，返回一个消息chan
-	msgChan := logger.ExchangeChans(errorChan)
+	return logger, nil
+}
 
-	// Check and replace previous logger.
-	//是否找到
-	hasFound := false
-	for i := range receivers {
-		//找到一种类型的消息处理器
-		if receivers[i].mode == mode {
-			hasFound = true
-
-			//是否前一个logger
-			// Release previous logger.
-			receivers[i].Destroy()
-
-			//定义日志和消息处理器
-			// Update info to new one.
-			receivers[i].Logger = logger
-			receivers[i].msgChan = msgChan
-			break
-		}
+//启动并加入消息处理器
+// install starts r and puts it in the receiver list in place of the one with same name,
+// which is returned with destroying counted, and must be released by the caller.
+func (c *Clog) install(r *receiver) (previous *receiver) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	//关闭后重新创建logger
+	if !c.handling {
+		c.startErrorHandling()
 	}
-	if !hasFound {
+
+	// Check and replace previous logger.
+	//找到同名的消息处理器
+	if i := c.findReceiver(MODE(r.name)); i >= 0 {
+		//定义日志和消息处理器
+		// Update info to new one.
+		previous = c.receivers[i]
+		receivers := make([]*receiver, len(c.receivers))
+		copy(receivers, c.receivers)
+		receivers[i] = r
+		c.receivers = receivers
+		c.destroying++
+	} else {
 		//如果没有找到
 		//新建一个消息处理器
-		receivers = append(receivers, &receiver{
-			Logger:  logger,
-			mode:    mode, //新增一个mode？
-			msgChan: msgChan,
-		})
+		c.receivers = append(c.receivers, r)
 	}
+	c.bindFallback(r)
 	//异步处理消息
-	go logger.Start()
+	go r.Start()
+	return previous
+}
+
+//写入文件的logger
+// fileReplacer is implemented by loggers writing to files.
+type fileReplacer interface {
+	// replacedOnFile returns true if the logger created with cfg replaces this one
+	// and writes to the same file.
+	replacedOnFile(cfg interface{}) bool
+}
+
+//取出将被替换并且写入同一文件的logger
+// takeSameFile removes the logger which is to be replaced by the one created with cfg
+// and writes to the same file from the receiver list. It returns the removed receiver
+// with destroying counted, which must be released by the caller, or nil if not found.
+func (c *Clog) takeSameFile(cfg interface{}) *receiver {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i, r := range c.receivers {
+		if f, ok := r.Logger.(fileReplacer); ok && f.replacedOnFile(cfg) {
+			receivers := make([]*receiver, 0, len(c.receivers)-1)
+			receivers = append(receivers, c.receivers[:i]...)
+			c.receivers = append(receivers, c.receivers[i+1:]...)
+			c.destroying++
+			return r
+		}
+	}
 	return nil
 }
 
+//丢弃没有使用的logger
+// discard destroys r which is initialized but never installed.
+func discard(r *receiver) {
+	go r.Start()
+	r.destroy()
+}
+
+// New initializes and appends a new logger to the receiver list of default instance.
+// Calling this function multiple times will overwrite previous logger with same name.
+func New(mode MODE, cfg interface{}) error {
+	return std.New(mode, cfg)
+}
+
 //删除一种类型的日志处理器
 //同时弥补空缺
-// Delete removes logger from the receiver list.
-func Delete(mode MODE) {
-	foundIdx := -1
-	for i := range receivers {
-		if receivers[i].mode == mode {
-			foundIdx = i
-			receivers[i].Destroy()
-		}
+// Delete removes logger of given name from the receiver list, and returns after the
+// logger processed remaining messages. Other loggers keep working meanwhile.
+func (c *Clog) Delete(name MODE) {
+	c.lock.Lock()
+	foundIdx := c.findReceiver(name)
+	if foundIdx < 0 {
+		c.lock.Unlock()
+		return
 	}
-
 	//拷贝receiver
-	if foundIdx >= 0 {
-		newList := make([]*receiver, len(receivers)-1)
-		copy(newList, receivers[:foundIdx])
-		copy(newList[foundIdx:], receivers[foundIdx+1:])
-		receivers = newList
+	r := c.receivers[foundIdx]
+	newList := make([]*receiver, len(c.receivers)-1)
+	copy(newList, c.receivers[:foundIdx])
+	copy(newList[foundIdx:], c.receivers[foundIdx+1:])
+	c.receivers = newList
+	c.destroying++
+	c.lock.Unlock()
+
+	// Destroy after unlocking, so other loggers are not blocked while it drains.
+	c.release(r)
+}
+
+// Delete removes logger of given name from the receiver list of default instance.
+func Delete(name MODE) {
+	std.Delete(name)
+}
+
+//修改级别
+// SetLevel changes minimum level of messages to be sent to the logger of given name,
+// without recreating the logger.
+func (c *Clog) SetLevel(name MODE, level LEVEL) error {
+	if !isValidLevel(level) {
+		return ErrInvalidLevel{}
 	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	i := c.findReceiver(name)
+	if i < 0 {
+		return fmt.Errorf("no logger with name '%s'", name)
+	}
+	atomic.StoreInt32(&c.receivers[i].level, int32(level))
+	return nil
+}
+
+// SetLevel changes minimum level of messages to be sent to the logger of given name in default instance.
+func SetLevel(name MODE, level LEVEL) error {
+	return std.SetLevel(name, level)
+}
+
+//获取级别
+// GetLevel returns minimum level of messages to be sent to the logger of given name.
+func (c *Clog) GetLevel(name MODE) (LEVEL, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	i := c.findReceiver(name)
+	if i < 0 {
+		return 0, fmt.Errorf("no logger with name '%s'", name)
+	}
+	return c.receivers[i].minLevel(), nil
+}
+
+// GetLevel returns minimum level of messages to be sent to the logger of given name in default instance.
+func GetLevel(name MODE) (LEVEL, error) {
+	return std.GetLevel(name)
 }
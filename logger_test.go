@@ -15,7 +15,9 @@
 package clog
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -46,3 +48,324 @@ func Test_Register(t *testing.T) {
 		So(err.Error(), ShouldContainSubstring, "unknown mode")
 	})
 }
+
+func Test_Clog_instance(t *testing.T) {
+	Convey("Independent logger instances", t, func() {
+		c1 := NewClog()
+		c2 := NewClog()
+		So(c1.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+		So(c2.New(_MEMORY, memoryConfig{
+			Level: ERROR,
+		}), ShouldBeNil)
+
+		buf.Reset()
+		wg.Add(1)
+		c1.Info("Level: %v", INFO)
+		c2.Info("Level: %v", INFO)
+		wg.Wait()
+		So(buf.String(), ShouldEqual, "[ INFO] Level: 1")
+
+		Convey("Delete receiver of one instance", func() {
+			c1.Delete(_MEMORY)
+			So(c1.receivers, ShouldBeEmpty)
+			So(c2.receivers, ShouldHaveLength, 1)
+
+			buf.Reset()
+			wg.Add(1)
+			c2.Error(0, "Level: %v", ERROR)
+			wg.Wait()
+			So(buf.String(), ShouldEqual, "[ERROR] Level: 3")
+		})
+
+		c1.Shutdown()
+		c2.Shutdown()
+	})
+}
+
+func Test_Clog_errorHandling(t *testing.T) {
+	Convey("Handle errors reported by loggers", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+
+		type handled struct {
+			mode MODE
+			err  error
+		}
+		errs := make(chan handled, 1)
+		c.SetErrorHandler(func(mode MODE, err error) {
+			errs <- handled{mode, err}
+		})
+
+		msg := &Message{Body: "test message"}
+		c.receivers[0].errorChan <- ErrWrite{Message: msg, Err: errors.New("boom")}
+		h := <-errs
+		So(h.mode, ShouldEqual, _MEMORY)
+		err, ok := h.err.(ErrWrite)
+		So(ok, ShouldBeTrue)
+		So(err.Mode, ShouldEqual, _MEMORY)
+		So(err.Message, ShouldEqual, msg)
+		So(err.Error(), ShouldEqual, "memory: boom")
+
+		Convey("Wrap untyped errors", func() {
+			c.receivers[0].errorChan <- errors.New("boom")
+			h := <-errs
+			So(h.err, ShouldResemble, ErrWrite{Mode: _MEMORY, Name: string(_MEMORY), Err: errors.New("boom")})
+		})
+
+		Convey("Route errors to fallback logger", func() {
+			So(c.New(CONSOLE, ConsoleConfig{}), ShouldBeNil)
+			c.SetErrorReceiver(_MEMORY)
+
+			buf.Reset()
+			wg.Add(1)
+			c.receivers[1].errorChan <- errors.New("boom")
+			wg.Wait()
+			So(buf.String(), ShouldEqual, "[ERROR] clog: unable to write message: console: boom")
+
+			Convey("Errors of fallback logger itself", func() {
+				c.receivers[0].errorChan <- errors.New("boom")
+				So((<-errs).mode, ShouldEqual, _MEMORY)
+			})
+
+			Convey("Fallback logger is recreated", func() {
+				So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+				So(c.fallback, ShouldEqual, c.receivers[0])
+
+				c.Delete(_MEMORY)
+				So(c.fallback, ShouldBeNil)
+			})
+		})
+
+		c.Shutdown()
+	})
+}
+
+func Test_Clog_SetLevel(t *testing.T) {
+	Convey("Change level of logger at runtime", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{
+			Level: ERROR,
+		}), ShouldBeNil)
+		r := c.receivers[0]
+
+		level, err := c.GetLevel(_MEMORY)
+		So(err, ShouldBeNil)
+		So(level, ShouldEqual, ERROR)
+
+		So(c.SetLevel(_MEMORY, TRACE), ShouldBeNil)
+		level, err = c.GetLevel(_MEMORY)
+		So(err, ShouldBeNil)
+		So(level, ShouldEqual, TRACE)
+		So(c.receivers[0], ShouldEqual, r)
+
+		buf.Reset()
+		wg.Add(1)
+		c.Trace("Level: %v", TRACE)
+		wg.Wait()
+		So(buf.String(), ShouldEqual, "[TRACE] Level: 0")
+
+		Convey("Incorrect level", func() {
+			err := c.SetLevel(_MEMORY, LEVEL(-1))
+			So(err, ShouldNotBeNil)
+			_, ok := err.(ErrInvalidLevel)
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("Non-existent logger", func() {
+			So(c.SetLevel(FILE, TRACE), ShouldNotBeNil)
+			_, err := c.GetLevel(FILE)
+			So(err, ShouldNotBeNil)
+		})
+
+		c.Shutdown()
+	})
+}
+
+func Test_Clog_named(t *testing.T) {
+	Convey("Loggers of same mode with different names", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{Name: "a"}), ShouldBeNil)
+		So(c.New(_MEMORY, memoryConfig{Name: "b", Level: WARN}), ShouldBeNil)
+		So(len(c.receivers), ShouldEqual, 2)
+
+		buf.Reset()
+		wg.Add(2)
+		c.Warn("Level: %v", WARN)
+		wg.Wait()
+		So(buf.String(), ShouldEqual, "[ WARN] Level: 2[ WARN] Level: 2")
+
+		Convey("Address logger by name", func() {
+			level, err := c.GetLevel("b")
+			So(err, ShouldBeNil)
+			So(level, ShouldEqual, WARN)
+			So(c.SetLevel("a", ERROR), ShouldBeNil)
+			So(c.receivers[0].minLevel(), ShouldEqual, ERROR)
+
+			_, err = c.GetLevel(_MEMORY)
+			So(err, ShouldNotBeNil)
+
+			stats := c.Stats()
+			So(stats[0].Mode, ShouldEqual, _MEMORY)
+			So(stats[0].Name, ShouldEqual, "a")
+			So(stats[1].Name, ShouldEqual, "b")
+		})
+
+		Convey("Replace logger with same name", func() {
+			So(c.New(_MEMORY, memoryConfig{Name: "a", Level: FATAL}), ShouldBeNil)
+			So(len(c.receivers), ShouldEqual, 2)
+			So(c.receivers[0].minLevel(), ShouldEqual, FATAL)
+
+			So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+			So(len(c.receivers), ShouldEqual, 3)
+			So(c.receivers[2].name, ShouldEqual, string(_MEMORY))
+		})
+
+		Convey("Delete logger by name", func() {
+			c.Delete(_MEMORY)
+			So(len(c.receivers), ShouldEqual, 2)
+
+			c.Delete("a")
+			So(len(c.receivers), ShouldEqual, 1)
+			So(c.receivers[0].name, ShouldEqual, "b")
+		})
+
+		Convey("Errors carry name of logger", func() {
+			errs := make(chan error, 1)
+			c.SetErrorHandler(func(mode MODE, err error) {
+				errs <- err
+			})
+			c.receivers[1].errorChan <- errors.New("boom")
+			err := <-errs
+			So(err.(ErrWrite).Name, ShouldEqual, "b")
+			So(err.Error(), ShouldEqual, "memory(b): boom")
+		})
+
+		c.Shutdown()
+	})
+}
+
+// blocking is a logger blocks on writing until release is closed.
+type blocking struct {
+	Adapter
+	release chan struct{}
+}
+
+func (b *blocking) Level() LEVEL             { return b.level }
+func (b *blocking) Init(v interface{}) error { return nil }
+func (b *blocking) ExchangeChans(errorChan chan<- error) chan *Message {
+	b.errorChan = errorChan
+	return b.msgChan
+}
+func (b *blocking) Start()   { b.run(func(*Message) { <-b.release }) }
+func (b *blocking) Destroy() { b.stop() }
+
+func Test_Clog_Delete(t *testing.T) {
+	Convey("Delete a slow logger without blocking others", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+		defer c.Shutdown()
+
+		slow := &blocking{
+			Adapter: Adapter{
+				name:     "slow",
+				msgChan:  make(chan *Message, 10),
+				quitChan: make(chan struct{}),
+			},
+			release: make(chan struct{}),
+		}
+		c.lock.Lock()
+		c.receivers = append(c.receivers, newReceiver(_MEMORY, slow, c.errorChan))
+		c.lock.Unlock()
+		go slow.Start()
+
+		wg.Add(1)
+		c.Info("queued")
+		wg.Wait()
+
+		deleted := make(chan struct{})
+		go func() {
+			c.Delete("slow")
+			close(deleted)
+		}()
+		for {
+			if _, err := c.GetLevel("slow"); err != nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		// Logging goes on while the slow logger drains.
+		done := make(chan struct{})
+		wg.Add(1)
+		go func() {
+			c.Info("not blocked")
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			So("logging is blocked", ShouldBeEmpty)
+		}
+
+		close(slow.release)
+		<-deleted
+	})
+
+	Convey("Delete a stuck logger while a writer is blocked on it", t, func() {
+		c := NewClog()
+		defer c.Shutdown()
+
+		stuck := &blocking{
+			Adapter: Adapter{
+				name:     "stuck",
+				msgChan:  make(chan *Message, 1),
+				quitChan: make(chan struct{}),
+			},
+			release: make(chan struct{}),
+		}
+		c.lock.Lock()
+		c.receivers = append(c.receivers, newReceiver(_MEMORY, stuck, c.errorChan))
+		c.lock.Unlock()
+		go stuck.Start()
+
+		// The first message is being written, the second fills the buffer,
+		// and the writer blocks on the third.
+		written := make(chan struct{})
+		go func() {
+			c.Info("1")
+			c.Info("2")
+			c.Info("3")
+			close(written)
+		}()
+		for len(stuck.msgChan) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		created := make(chan error)
+		go func() {
+			created <- c.New(_MEMORY, memoryConfig{})
+		}()
+		select {
+		case err := <-created:
+			So(err, ShouldBeNil)
+		case <-time.After(5 * time.Second):
+			So("creating logger is blocked", ShouldBeEmpty)
+		}
+
+		deleted := make(chan struct{})
+		go func() {
+			c.Delete("stuck")
+			close(deleted)
+		}()
+		select {
+		case <-written:
+		case <-time.After(5 * time.Second):
+			So("writer is still blocked", ShouldBeEmpty)
+		}
+
+		close(stuck.release)
+		<-deleted
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import "strings"
+
+//模块的日志入口
+// Module returns an entry whose messages are attached with given module name,
+// e.g. Module("db").Trace("query: %s", q). Names are hierarchical and separated
+// by dots, e.g. "db.pool" is a sub-module of "db".
+func (c *Clog) Module(name string) *Entry {
+	return &Entry{
+		clog:   c,
+		module: name,
+	}
+}
+
+// Module returns an entry of default instance whose messages are attached with given module name.
+func Module(name string) *Entry {
+	return std.Module(name)
+}
+
+//子模块的日志入口
+// Module returns a new entry of sub-module with given name, fields are kept,
+// e.g. Module("db").Module("pool") is same as Module("db.pool").
+func (e *Entry) Module(name string) *Entry {
+	if len(e.module) > 0 {
+		name = e.module + "." + name
+	}
+	return &Entry{
+		clog:   e.clog,
+		module: name,
+		fields: e.fields,
+	}
+}
+
+//设置模块的级别
+// SetModuleLevel sets minimum level of messages to be sent to receivers for
+// given module and its sub-modules which have no level of their own.
+// Empty name is the root module, whose level applies to messages without module
+// and to modules with no level in their hierarchy, e.g. set TRACE for "db" and INFO
+// for root to get TRACE messages only from "db" with a TRACE receiver.
+// Receivers still skip messages lower than their own levels.
+func (c *Clog) SetModuleLevel(name string, level LEVEL) error {
+	if !isValidLevel(level) {
+		return ErrInvalidLevel{}
+	}
+
+	c.lock.Lock()
+	c.modules[name] = level
+	c.lock.Unlock()
+	return nil
+}
+
+// SetModuleLevel sets minimum level of messages for given module of default instance.
+func SetModuleLevel(name string, level LEVEL) error {
+	return std.SetModuleLevel(name, level)
+}
+
+//删除模块的级别
+// DeleteModuleLevel removes level of given module, the module then inherits
+// level from its parent modules.
+func (c *Clog) DeleteModuleLevel(name string) {
+	c.lock.Lock()
+	delete(c.modules, name)
+	c.lock.Unlock()
+}
+
+// DeleteModuleLevel removes level of given module of default instance.
+func DeleteModuleLevel(name string) {
+	std.DeleteModuleLevel(name)
+}
+
+//查找模块的级别，包括父模块
+// moduleLevel returns minimum level of given module, levels of parent modules
+// and then the root module are looked up when the module has no level of its own.
+// The lock must be held.
+func (c *Clog) moduleLevel(name string) (LEVEL, bool) {
+	for {
+		if level, ok := c.modules[name]; ok {
+			return level, true
+		}
+		if len(name) == 0 {
+			return 0, false
+		}
+
+		//没有父模块时查找根模块
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			i = 0
+		}
+		name = name[:i]
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Clog_moduleLevel(t *testing.T) {
+	Convey("Look up level of modules", t, func() {
+		c := NewClog()
+		So(c.SetModuleLevel("db", WARN), ShouldBeNil)
+		So(c.SetModuleLevel("db.pool", TRACE), ShouldBeNil)
+
+		level, ok := c.moduleLevel("db")
+		So(ok, ShouldBeTrue)
+		So(level, ShouldEqual, WARN)
+
+		level, ok = c.moduleLevel("db.pool.conn")
+		So(ok, ShouldBeTrue)
+		So(level, ShouldEqual, TRACE)
+
+		level, ok = c.moduleLevel("db.query")
+		So(ok, ShouldBeTrue)
+		So(level, ShouldEqual, WARN)
+
+		_, ok = c.moduleLevel("dbx")
+		So(ok, ShouldBeFalse)
+		_, ok = c.moduleLevel("")
+		So(ok, ShouldBeFalse)
+
+		Convey("Fall back to root module", func() {
+			So(c.SetModuleLevel("", INFO), ShouldBeNil)
+			level, ok = c.moduleLevel("dbx.pool")
+			So(ok, ShouldBeTrue)
+			So(level, ShouldEqual, INFO)
+			level, ok = c.moduleLevel("")
+			So(ok, ShouldBeTrue)
+			So(level, ShouldEqual, INFO)
+		})
+
+		Convey("Delete level of module", func() {
+			c.DeleteModuleLevel("db.pool")
+			level, ok = c.moduleLevel("db.pool")
+			So(ok, ShouldBeTrue)
+			So(level, ShouldEqual, WARN)
+		})
+
+		Convey("Incorrect level", func() {
+			err := c.SetModuleLevel("db", LEVEL(-1))
+			So(err, ShouldNotBeNil)
+			_, ok := err.(ErrInvalidLevel)
+			So(ok, ShouldBeTrue)
+		})
+
+		c.Shutdown()
+	})
+}
+
+func Test_Module(t *testing.T) {
+	Convey("Logging with modules", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+		So(c.SetModuleLevel("db", WARN), ShouldBeNil)
+
+		buf.Reset()
+		wg.Add(1)
+		c.Module("db").Info("skipped")
+		c.Module("db").Warn("Level: %v", WARN)
+		wg.Wait()
+		So(buf.String(), ShouldEqual, "[ WARN] [db] Level: 2")
+
+		Convey("Sub-module inherits level", func() {
+			buf.Reset()
+			wg.Add(1)
+			c.Module("db").Module("pool").WithFields("k", "v").Trace("skipped")
+			c.Module("db").Module("pool").WithFields("k", "v").Error(0, "Level: %v", ERROR)
+			wg.Wait()
+			So(buf.String(), ShouldEqual, "[ERROR] [db.pool] Level: 3 k=v")
+		})
+
+		Convey("Module without level", func() {
+			buf.Reset()
+			wg.Add(1)
+			c.Module("http").Trace("Level: %v", TRACE)
+			wg.Wait()
+			So(buf.String(), ShouldEqual, "[TRACE] [http] Level: 0")
+		})
+
+		Convey("Root level applies to messages without module", func() {
+			So(c.SetModuleLevel("", INFO), ShouldBeNil)
+			So(c.SetModuleLevel("db", TRACE), ShouldBeNil)
+
+			buf.Reset()
+			wg.Add(2)
+			c.Trace("skipped")
+			c.Module("http").Trace("skipped")
+			c.Module("db").Trace("Level: %v", TRACE)
+			c.Info("Level: %v", INFO)
+			wg.Wait()
+			So(buf.String(), ShouldEqual, "[TRACE] [db] Level: 0[ INFO] Level: 1")
+		})
+
+		c.Shutdown()
+	})
+}
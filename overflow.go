@@ -0,0 +1,163 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+//缓冲区满时的处理方式
+// OVERFLOW is the policy to apply when message buffer of a logger is full.
+type OVERFLOW int
+
+const (
+	// BLOCK waits until there is room in the buffer.
+	BLOCK OVERFLOW = iota //阻塞
+	// DROP_NEWEST discards the message being logged.
+	DROP_NEWEST //丢弃最新的消息
+	// DROP_OLDEST discards the oldest message in the buffer to make room.
+	DROP_OLDEST //丢弃最旧的消息
+	// BLOCK_TIMEOUT waits for a period of time, then discards the message being logged.
+	BLOCK_TIMEOUT //阻塞一段时间
+)
+
+//处理方式的名字
+var overflowNames = map[OVERFLOW]string{
+	BLOCK:         "BLOCK",
+	DROP_NEWEST:   "DROP_NEWEST",
+	DROP_OLDEST:   "DROP_OLDEST",
+	BLOCK_TIMEOUT: "BLOCK_TIMEOUT",
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so policies can be decoded
+// by name case-insensitively, e.g. "drop_oldest" for DROP_OLDEST.
+func (o *OVERFLOW) UnmarshalText(text []byte) error {
+	for overflow, name := range overflowNames {
+		if strings.EqualFold(string(text), name) {
+			*o = overflow
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown overflow policy '%s'", text)
+}
+
+//是否可用
+// isValidOverflow returns true if given policy is in the valid range.
+func isValidOverflow(overflow OVERFLOW) bool {
+	return overflow >= BLOCK && overflow <= BLOCK_TIMEOUT
+}
+
+//设置溢出处理方式
+// setOverflow validates and sets overflow policy of the logger. The timeout must be
+// positive for BLOCK_TIMEOUT, otherwise every message is dropped once the buffer is full.
+func (a *Adapter) setOverflow(overflow OVERFLOW, timeout time.Duration) error {
+	if !isValidOverflow(overflow) {
+		return ErrInvalidOverflow{}
+	}
+	if overflow == BLOCK_TIMEOUT && timeout <= 0 {
+		return errors.New("overflow timeout must be positive for BLOCK_TIMEOUT")
+	}
+	a.overflow = overflow
+	a.overflowTimeout = timeout
+	return nil
+}
+
+//按照处理方式发送消息
+// send sends message to the logger in respect of its overflow policy.
+// The message is discarded if the receiver is being destroyed.
+func (r *receiver) send(msg *Message) {
+	r.sending.RLock()
+	defer r.sending.RUnlock()
+	if r.closed {
+		return
+	}
+
+	switch r.overflow {
+	case DROP_NEWEST:
+		select {
+		case r.msgChan <- msg:
+		default:
+			atomic.AddInt64(&r.counters.dropped, 1)
+		}
+
+	case DROP_OLDEST:
+		for {
+			select {
+			case r.msgChan <- msg:
+				return
+			default:
+			}
+
+			// Make room by discarding the oldest message, there is nothing
+			// to discard for a logger without buffer.
+			if cap(r.msgChan) == 0 {
+				atomic.AddInt64(&r.counters.dropped, 1)
+				return
+			}
+			select {
+			case m := <-r.msgChan:
+				// Keep the flush marker, discard the message being logged instead.
+				if m.flushed != nil {
+					msg = m
+				}
+				atomic.AddInt64(&r.counters.dropped, 1)
+			default:
+			}
+		}
+
+	case BLOCK_TIMEOUT:
+		select {
+		case r.msgChan <- msg:
+			return
+		default:
+		}
+
+		timer := time.NewTimer(r.overflowTimeout)
+		defer timer.Stop()
+		select {
+		case r.msgChan <- msg:
+		case <-timer.C:
+			atomic.AddInt64(&r.counters.dropped, 1)
+		case <-r.closing:
+		}
+
+	default:
+		select {
+		case r.msgChan <- msg:
+		case <-r.closing:
+		}
+	}
+}
+
+//丢弃的消息数
+// Dropped returns number of messages dropped by the logger of given name due to overflow.
+func (c *Clog) Dropped(name MODE) int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if i := c.findReceiver(name); i >= 0 {
+		return atomic.LoadInt64(&c.receivers[i].counters.dropped)
+	}
+	return 0
+}
+
+// Dropped returns number of messages dropped by the logger of given name in default instance.
+func Dropped(name MODE) int64 {
+	return std.Dropped(name)
+}
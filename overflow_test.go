@@ -0,0 +1,97 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_isValidOverflow(t *testing.T) {
+	Convey("Validate overflow policy", t, func() {
+		So(isValidOverflow(OVERFLOW(-1)), ShouldBeFalse)
+		So(isValidOverflow(OVERFLOW(4)), ShouldBeFalse)
+		So(isValidOverflow(BLOCK), ShouldBeTrue)
+		So(isValidOverflow(BLOCK_TIMEOUT), ShouldBeTrue)
+	})
+}
+
+func Test_receiver_send(t *testing.T) {
+	Convey("Send messages to a full buffer", t, func() {
+		m1 := &Message{Body: "1"}
+		m2 := &Message{Body: "2"}
+
+		Convey("Drop newest", func() {
+			r := &receiver{
+				msgChan:  make(chan *Message, 1),
+				overflow: DROP_NEWEST,
+				counters: new(counters),
+			}
+			r.send(m1)
+			r.send(m2)
+			So(r.counters.dropped, ShouldEqual, 1)
+			So(<-r.msgChan, ShouldEqual, m1)
+		})
+
+		Convey("Drop oldest", func() {
+			r := &receiver{
+				msgChan:  make(chan *Message, 1),
+				overflow: DROP_OLDEST,
+				counters: new(counters),
+			}
+			r.send(m1)
+			r.send(m2)
+			So(r.counters.dropped, ShouldEqual, 1)
+			So(<-r.msgChan, ShouldEqual, m2)
+
+			Convey("Without buffer", func() {
+				r.msgChan = make(chan *Message)
+				r.send(m1)
+				So(r.counters.dropped, ShouldEqual, 2)
+			})
+		})
+
+		Convey("Block with timeout", func() {
+			r := &receiver{
+				msgChan:         make(chan *Message, 1),
+				overflow:        BLOCK_TIMEOUT,
+				overflowTimeout: 10 * time.Millisecond,
+				counters:        new(counters),
+			}
+			r.send(m1)
+			start := time.Now()
+			r.send(m2)
+			So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 10*time.Millisecond)
+			So(r.counters.dropped, ShouldEqual, 1)
+			So(<-r.msgChan, ShouldEqual, m1)
+		})
+	})
+}
+
+func Test_Dropped(t *testing.T) {
+	Convey("Get number of dropped messages", t, func() {
+		c := &Clog{
+			receivers: []*receiver{{
+				mode:     _MEMORY,
+				name:     string(_MEMORY),
+				counters: &counters{dropped: 3},
+			}},
+		}
+		So(c.Dropped(_MEMORY), ShouldEqual, 3)
+		So(c.Dropped(FILE), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import "runtime/debug"
+
+//处理panic
+// handlePanic logs panic value with stack trace of current goroutine as FATAL message
+// to all receivers, shuts down the instance so the message is written, then panics
+// again with the same value.
+func (c *Clog) handlePanic(v interface{}) {
+	c.write(FATAL, 0, "", nil, "panic: %v\n\n%s", v, debug.Stack())
+	c.Shutdown()
+	panic(v)
+}
+
+//记录panic
+// Recover logs the panic of current goroutine if any, along with full stack trace,
+// then shuts down the instance and panics again. It must be called directly by defer:
+//
+//	defer logger.Recover()
+func (c *Clog) Recover() {
+	if v := recover(); v != nil {
+		c.handlePanic(v)
+	}
+}
+
+//运行协程并记录panic
+// Go runs f in a new goroutine, panic of which is logged as Recover does.
+func (c *Clog) Go(f func()) {
+	go func() {
+		defer c.Recover()
+		f()
+	}()
+}
+
+// Recover logs the panic of current goroutine if any with default instance, then shuts
+// it down and panics again. It must be called directly by defer:
+//
+//	defer log.Recover()
+func Recover() {
+	// recover only works when called directly by deferred function.
+	if v := recover(); v != nil {
+		std.handlePanic(v)
+	}
+}
+
+// Go runs f in a new goroutine, panic of which is logged with default instance.
+func Go(f func()) {
+	std.Go(f)
+}
@@ -0,0 +1,149 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Clog_Recover(t *testing.T) {
+	Convey("Log panic with stack trace and panic again", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{
+			Level:      ERROR,
+			BufferSize: 10,
+		}), ShouldBeNil)
+
+		buf.Reset()
+		wg.Add(1)
+		var recovered interface{}
+		func() {
+			defer func() {
+				recovered = recover()
+			}()
+			defer c.Recover()
+			panic("boom")
+		}()
+
+		So(recovered, ShouldEqual, "boom")
+		So(buf.String(), ShouldStartWith, "[FATAL] panic: boom")
+		So(buf.String(), ShouldContainSubstring, "panic_test.go")
+		So(c.receivers, ShouldBeEmpty)
+	})
+
+	Convey("Locate the panicking function as caller", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{
+			Level:      ERROR,
+			BufferSize: 10,
+			Caller:     true,
+		}), ShouldBeNil)
+
+		buf.Reset()
+		wg.Add(1)
+		func() {
+			defer func() {
+				recover()
+			}()
+			defer c.Recover()
+			panic("boom")
+		}()
+
+		line := strings.SplitN(buf.String(), "\n", 2)[0]
+		So(line, ShouldStartWith, "[FATAL] [")
+		So(line, ShouldContainSubstring, "panic_test.go:")
+		So(line, ShouldNotContainSubstring, "gopanic")
+	})
+
+	Convey("Log panic with default instance", t, func() {
+		// Use a fresh default instance to not touch receivers of other tests.
+		prev := std
+		std = NewClog()
+		defer func() {
+			std = prev
+		}()
+		So(New(_MEMORY, memoryConfig{
+			Level:      ERROR,
+			BufferSize: 10,
+		}), ShouldBeNil)
+
+		buf.Reset()
+		wg.Add(1)
+		var recovered interface{}
+		func() {
+			defer func() {
+				recovered = recover()
+			}()
+			defer Recover()
+			panic("boom")
+		}()
+
+		So(recovered, ShouldEqual, "boom")
+		So(buf.String(), ShouldStartWith, "[FATAL] panic: boom")
+		So(std.receivers, ShouldBeEmpty)
+	})
+
+	Convey("Do nothing without panic", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+		defer c.Shutdown()
+
+		done := make(chan struct{})
+		c.Go(func() {
+			close(done)
+		})
+		<-done
+		So(c.receivers, ShouldHaveLength, 1)
+	})
+}
+
+// The panic inside goroutine started by Go crashes the process,
+// so it is tested in a child process.
+func Test_Clog_Go(t *testing.T) {
+	const filename = "test/go_panic.log"
+	if os.Getenv("CLOG_TEST_GO_PANIC") == "1" {
+		c := NewClog()
+		if err := c.New(FILE, FileConfig{Filename: filename}); err != nil {
+			t.Fatal(err)
+		}
+		c.Go(func() {
+			panic("boom in goroutine")
+		})
+		time.Sleep(10 * time.Second)
+		return
+	}
+
+	Convey("Log panic inside goroutine and crash", t, func() {
+		os.Remove(filename)
+
+		cmd := exec.Command(os.Args[0], "-test.run=^Test_Clog_Go$")
+		cmd.Env = append(os.Environ(), "CLOG_TEST_GO_PANIC=1")
+		out, err := cmd.CombinedOutput()
+		So(err, ShouldNotBeNil)
+		So(string(out), ShouldContainSubstring, "panic: boom in goroutine")
+
+		data, err := ioutil.ReadFile(filename)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "[FATAL] panic: boom in goroutine")
+		So(string(data), ShouldContainSubstring, "panic_test.go")
+	})
+}
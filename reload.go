@@ -0,0 +1,210 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+//除了级别以外的配置项是否相同
+// sameExceptLevel returns true if two sets of options only differ in level.
+func sameExceptLevel(a, b map[string]interface{}) bool {
+	a2 := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		a2[k] = v
+	}
+	b2 := make(map[string]interface{}, len(b))
+	for k, v := range b {
+		b2[k] = v
+	}
+	delete(a2, "level")
+	delete(b2, "level")
+	return reflect.DeepEqual(a2, b2)
+}
+
+//重新加载配置文件
+// Reload re-reads configuration file and applies differences to loggers created by
+// LoadConfig or previous Reload: new loggers are created, loggers no longer listed are
+// deleted, loggers only differ in level have level changed in place, and other changed
+// loggers are recreated after buffered messages are written. Loggers created by New are
+// left untouched unless the file has loggers with same names.
+//
+// All configs are decoded and new loggers are initialized before anything is changed,
+// so an invalid config leaves loggers as they were. The exception is a recreated logger
+// writing to the same file as before, which is initialized after the old one is released
+// to not write to or rotate the file at the same time. If it fails, the logger is deleted
+// and the error says so, other changes are still applied.
+func (c *Clog) Reload(path string) error {
+	configs, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	//当前来自配置文件的logger
+	c.lock.RLock()
+	current := make(map[string]*receiver, len(c.receivers))
+	for _, r := range c.receivers {
+		if r.options != nil {
+			current[r.name] = r
+		}
+	}
+	c.lock.RUnlock()
+
+	//只修改级别的logger
+	type levelChange struct {
+		r       *receiver
+		level   LEVEL
+		options map[string]interface{}
+	}
+	var levels []levelChange
+	//需要新建的logger，写入同一文件的在替换时才初始化
+	var recreated []*receiverConfig
+	built := make(map[string]*receiver)
+	for _, rc := range configs {
+		r, ok := current[rc.name]
+		delete(current, rc.name)
+		if ok && reflect.DeepEqual(r.options, rc.options) {
+			continue
+		}
+
+		//只修改了级别
+		if ok && r.mode == rc.mode && sameExceptLevel(r.options, rc.options) {
+			var v struct{ Level LEVEL }
+			if DecodeMap(map[string]interface{}{"level": rc.options["level"]}, &v) == nil && isValidLevel(v.Level) {
+				levels = append(levels, levelChange{r, v.Level, rc.options})
+				continue
+			}
+		}
+
+		recreated = append(recreated, rc)
+		if ok {
+			if f, isFile := r.Logger.(fileReplacer); isFile && f.replacedOnFile(rc.cfg) {
+				continue
+			}
+		}
+
+		logger, err := newLogger(rc.mode, rc.cfg)
+		if err != nil {
+			for _, r := range built {
+				discard(r)
+			}
+			return fmt.Errorf("%s: %v", rc.name, err)
+		}
+		r = newReceiver(rc.mode, logger, c.errorChan)
+		r.options = rc.options
+		built[rc.name] = r
+	}
+
+	for _, l := range levels {
+		c.lock.Lock()
+		atomic.StoreInt32(&l.r.level, int32(l.level))
+		l.r.options = l.options
+		c.lock.Unlock()
+	}
+
+	//删除不再存在的logger
+	for name := range current {
+		c.Delete(MODE(name))
+	}
+
+	for _, rc := range recreated {
+		if r, ok := built[rc.name]; ok {
+			if previous := c.install(r); previous != nil {
+				c.release(previous)
+			}
+			continue
+		}
+		if e := c.add(rc.mode, rc.cfg, rc.options); e != nil && err == nil {
+			err = fmt.Errorf("%s: %v, the logger is deleted", rc.name, e)
+		}
+	}
+	return err
+}
+
+// Reload re-reads configuration file and applies differences to loggers of default instance.
+func Reload(path string) error {
+	return std.Reload(path)
+}
+
+//修改时间，文件不存在时为零值
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+//监视配置文件
+// WatchConfig reloads configuration file on SIGHUP, and also when its modification time
+// changes if interval is greater than 0, which is how often to check. Errors of reloading
+// go to error handler or error receiver. It returns a function to stop watching.
+func (c *Clog) WatchConfig(path string, interval time.Duration) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		tick = ticker.C
+	}
+
+	lastMod := modTime(path)
+	quit := make(chan struct{})
+	go func() {
+		defer signal.Stop(sigs)
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+
+		for {
+			select {
+			case <-sigs:
+			case <-tick:
+				if modTime(path).Equal(lastMod) {
+					continue
+				}
+			case <-quit:
+				return
+			}
+
+			lastMod = modTime(path)
+			if err := c.Reload(path); err != nil {
+				c.handleError(fmt.Errorf("reload: %v", err))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+	}
+}
+
+// WatchConfig reloads configuration file of default instance on SIGHUP or modification.
+func WatchConfig(path string, interval time.Duration) (stop func()) {
+	return std.WatchConfig(path, interval)
+}
@@ -0,0 +1,182 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const reloadConfig = `
+receivers:
+  - mode: console
+    level: info
+  - mode: file
+    name: errors
+    level: error
+    filename: test/reload.log
+`
+
+func Test_Clog_Reload(t *testing.T) {
+	Convey("Reload configuration file", t, func() {
+		os.MkdirAll("test", os.ModePerm)
+		So(ioutil.WriteFile("test/reload.yaml", []byte(reloadConfig), os.ModePerm), ShouldBeNil)
+
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{}), ShouldBeNil)
+		So(c.LoadConfig("test/reload.yaml"), ShouldBeNil)
+		So(len(c.receivers), ShouldEqual, 3)
+		console, errors := c.receivers[1], c.receivers[2]
+
+		Convey("Nothing changed", func() {
+			So(c.Reload("test/reload.yaml"), ShouldBeNil)
+			So(c.receivers[1], ShouldEqual, console)
+			So(c.receivers[2], ShouldEqual, errors)
+		})
+
+		Convey("Change level in place", func() {
+			So(ioutil.WriteFile("test/reload.yaml", []byte(`
+receivers:
+  - mode: console
+    level: trace
+  - mode: file
+    name: errors
+    level: error
+    filename: test/reload.log
+`), os.ModePerm), ShouldBeNil)
+			So(c.Reload("test/reload.yaml"), ShouldBeNil)
+			So(c.receivers[1], ShouldEqual, console)
+			So(console.minLevel(), ShouldEqual, TRACE)
+			So(c.receivers[2], ShouldEqual, errors)
+		})
+
+		Convey("Recreate, add and delete loggers", func() {
+			errors.msgChan <- &Message{Level: ERROR, Time: time.Now(), Body: "buffered"}
+			So(ioutil.WriteFile("test/reload.yaml", []byte(`
+receivers:
+  - mode: file
+    name: errors
+    level: warn
+    filename: test/reload.log
+    encoding: json
+    rotate: true
+  - mode: file
+    name: all
+    filename: test/reload-all.log
+`), os.ModePerm), ShouldBeNil)
+			So(c.Reload("test/reload.yaml"), ShouldBeNil)
+			So(len(c.receivers), ShouldEqual, 3)
+			So(c.receivers[0].mode, ShouldEqual, _MEMORY)
+			// Compare pointers only, rendering a running logger on failure races with it.
+			So(c.receivers[1] != errors, ShouldBeTrue)
+			So(c.receivers[1].name, ShouldEqual, "errors")
+			So(c.receivers[1].minLevel(), ShouldEqual, WARN)
+			So(c.receivers[2].name, ShouldEqual, "all")
+
+			data, err := ioutil.ReadFile("test/reload.log")
+			So(err, ShouldBeNil)
+			So(string(data), ShouldContainSubstring, "[ERROR] buffered")
+
+			// The old logger is released before the new one counts the same file.
+			f := c.receivers[1].Logger.(*file)
+			f.lock.Lock()
+			size := f.counter.size
+			f.lock.Unlock()
+			So(size, ShouldEqual, int64(len(data)))
+		})
+
+		Convey("Change nothing when a new logger fails", func() {
+			So(ioutil.WriteFile("test/reload.yaml", []byte(`
+receivers:
+  - mode: file
+    name: errors
+    level: warn
+    filename: test/reload-errors.log
+  - mode: file
+    name: b
+    filename: test/reload-b.log
+    interval: 90s
+`), os.ModePerm), ShouldBeNil)
+			So(c.Reload("test/reload.yaml"), ShouldNotBeNil)
+			So(len(c.receivers), ShouldEqual, 3)
+			So(c.receivers[1], ShouldEqual, console)
+			So(c.receivers[2], ShouldEqual, errors)
+			So(errors.minLevel(), ShouldEqual, ERROR)
+		})
+
+		Convey("Report logger failed to recreate on the same file", func() {
+			So(ioutil.WriteFile("test/reload.yaml", []byte(`
+receivers:
+  - mode: console
+    level: info
+  - mode: file
+    name: errors
+    level: error
+    filename: test/reload.log
+    interval: 90s
+`), os.ModePerm), ShouldBeNil)
+			err := c.Reload("test/reload.yaml")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "the logger is deleted")
+			So(len(c.receivers), ShouldEqual, 2)
+			So(c.receivers[1], ShouldEqual, console)
+		})
+
+		Convey("Invalid config", func() {
+			So(ioutil.WriteFile("test/reload.yaml", []byte(`receivers: [{mode: console, level: verbose}]`), os.ModePerm), ShouldBeNil)
+			So(c.Reload("test/reload.yaml"), ShouldNotBeNil)
+			So(len(c.receivers), ShouldEqual, 3)
+		})
+
+		c.Shutdown()
+		os.Remove("test/reload.log")
+		os.Remove("test/reload-errors.log")
+	})
+}
+
+func Test_Clog_WatchConfig(t *testing.T) {
+	Convey("Reload configuration file when it is modified", t, func() {
+		os.MkdirAll("test", os.ModePerm)
+		So(ioutil.WriteFile("test/watch.yaml", []byte(`receivers: [{mode: console, level: info}]`), os.ModePerm), ShouldBeNil)
+
+		c := NewClog()
+		So(c.LoadConfig("test/watch.yaml"), ShouldBeNil)
+		stop := c.WatchConfig("test/watch.yaml", 10*time.Millisecond)
+		defer stop()
+
+		So(ioutil.WriteFile("test/watch.yaml", []byte(`receivers: [{mode: console, level: warn}]`), os.ModePerm), ShouldBeNil)
+		future := time.Now().Add(time.Hour)
+		So(os.Chtimes("test/watch.yaml", future, future), ShouldBeNil)
+
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if level, _ := c.GetLevel(CONSOLE); level == WARN {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		level, err := c.GetLevel(CONSOLE)
+		So(err, ShouldBeNil)
+		So(level, ShouldEqual, WARN)
+
+		stop()
+		stop()
+		c.Shutdown()
+	})
+}
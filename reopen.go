@@ -0,0 +1,92 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+//可以重新打开输出的logger
+// reopener is implemented by loggers which can reopen their output, e.g. file.
+type reopener interface {
+	Reopen() error
+}
+
+//重新打开所有的文件
+// Reopen makes loggers which write to files close and reopen them between messages,
+// it is for external tools like logrotate moving files away. All loggers are reopened
+// even if some of them fail, and the first error is returned.
+func (c *Clog) Reopen() error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var firstErr error
+	for _, r := range c.receivers {
+		ro, ok := r.Logger.(reopener)
+		if !ok {
+			continue
+		}
+		if err := ro.Reopen(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %v", r.name, err)
+		}
+	}
+	return firstErr
+}
+
+// Reopen makes loggers of default instance which write to files close and reopen them.
+func Reopen() error {
+	return std.Reopen()
+}
+
+//收到信号时重新打开文件
+// ReopenOnSignal calls Reopen whenever one of given signals is received, SIGHUP if none,
+// e.g. ReopenOnSignal(syscall.SIGUSR1) to work with logrotate's postrotate script.
+// Errors go to error handler or error receiver. It returns a function to stop handling.
+func (c *Clog) ReopenOnSignal(sig ...os.Signal) (stop func()) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, sig...)
+
+	quit := make(chan struct{})
+	go func() {
+		defer signal.Stop(sigs)
+		for {
+			select {
+			case <-sigs:
+				if err := c.Reopen(); err != nil {
+					c.handleError(fmt.Errorf("reopen: %v", err))
+				}
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(quit) })
+	}
+}
+
+// ReopenOnSignal calls Reopen of default instance whenever one of given signals is received.
+func ReopenOnSignal(sig ...os.Signal) (stop func()) {
+	return std.ReopenOnSignal(sig...)
+}
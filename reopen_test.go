@@ -0,0 +1,89 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_Clog_Reopen(t *testing.T) {
+	Convey("Reopen files after they are moved", t, func() {
+		os.Remove("test/reopen.log")
+		os.Remove("test/reopen.log.1")
+
+		c := NewClog()
+		So(c.New(FILE, FileConfig{
+			Filename: "test/reopen.log",
+		}), ShouldBeNil)
+
+		c.Info("before")
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if data, _ := ioutil.ReadFile("test/reopen.log"); len(data) > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		So(os.Rename("test/reopen.log", "test/reopen.log.1"), ShouldBeNil)
+		So(c.Reopen(), ShouldBeNil)
+
+		c.Info("after")
+		c.Shutdown()
+
+		data, err := ioutil.ReadFile("test/reopen.log.1")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "before")
+		So(string(data), ShouldNotContainSubstring, "after")
+
+		data, err = ioutil.ReadFile("test/reopen.log")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "after")
+	})
+}
+
+func Test_file_CopyTruncate(t *testing.T) {
+	Convey("Detect file truncated by external tools", t, func() {
+		os.Remove("test/truncate.log")
+
+		w, err := NewFileWriter("test/truncate.log", FileRotationConfig{
+			Rotate:       true,
+			MaxSize:      1 << 20,
+			CopyTruncate: true,
+		})
+		So(err, ShouldBeNil)
+		f := w.(*file)
+
+		_, err = w.Write([]byte("first message"))
+		So(err, ShouldBeNil)
+		So(f.counter.size, ShouldBeGreaterThan, 0)
+		So(f.counter.lines, ShouldEqual, 1)
+
+		So(os.Truncate("test/truncate.log", 0), ShouldBeNil)
+		n, err := w.Write([]byte("second"))
+		So(err, ShouldBeNil)
+		So(f.counter.lines, ShouldEqual, 1)
+
+		data, err := ioutil.ReadFile("test/truncate.log")
+		So(err, ShouldBeNil)
+		So(f.counter.size, ShouldEqual, len(data))
+		So(n, ShouldEqual, len("second"))
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+//全是数字
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+//解析分割出的文件名
+// parseRotatedName returns date and sequence number encoded in name of a rotated file,
+// where date has resolution of day, hour or minute. The ok is false if the name does not
+// follow the naming template.
+func (f *file) parseRotatedName(name string) (date time.Time, seq int, ok bool) {
+	naming, err := f.naming()
+	if err != nil {
+		return date, 0, false
+	}
+	s, seq, ok := naming.split(name)
+	if !ok {
+		return date, 0, false
+	}
+	for _, layout := range rotateLayouts {
+		if len(layout) == len(s) {
+			date, err = time.ParseInLocation(layout, s, f.timeLocation())
+			break
+		}
+	}
+	return date, seq, err == nil
+}
+
+//分割出的文件
+type backup struct {
+	path string
+	date time.Time
+	seq  int
+	size int64
+}
+
+//从新到旧排序
+type backupsByNewest []backup
+
+func (b backupsByNewest) Len() int      { return len(b) }
+func (b backupsByNewest) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b backupsByNewest) Less(i, j int) bool {
+	if b[i].date.Equal(b[j].date) {
+		return b[i].seq > b[j].seq
+	}
+	return b[i].date.After(b[j].date)
+}
+
+//删除过期的文件
+// deleteOutdatedFiles removes rotated files exceed any of MaxDays, MaxBackups and MaxTotalSize.
+// Only files follow the naming scheme are considered, and they are ordered by date and
+// sequence number in names. Errors are reported to error channel.
+func (f *file) deleteOutdatedFiles() {
+	if f.rotate.MaxDays <= 0 && f.rotate.MaxBackups <= 0 && f.rotate.MaxTotalSize <= 0 {
+		return
+	}
+
+	dir := filepath.Dir(f.filename)
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		f.reportError(nil, fmt.Errorf("ReadDir '%s': %v", dir, err))
+		return
+	}
+
+	var backups []backup
+	for _, fi := range infos {
+		//当前文件的名字也可能符合模板
+		if fi.IsDir() || fi.Name() == filepath.Base(f.filename) {
+			continue
+		}
+		date, seq, ok := f.parseRotatedName(fi.Name())
+		if !ok {
+			continue
+		}
+		backups = append(backups, backup{
+			path: filepath.Join(dir, fi.Name()),
+			date: date,
+			seq:  seq,
+			size: fi.Size(),
+		})
+	}
+	sort.Sort(backupsByNewest(backups))
+
+	// A file is outdated after the end of period in its name.
+	period := f.interval
+	if period == 0 {
+		period = 24 * time.Hour
+	}
+	deadline := time.Now().Add(-24 * time.Hour * time.Duration(f.rotate.MaxDays))
+	var (
+		count     int64
+		totalSize int64
+		//超过了数量或大小，更旧的文件都要删除
+		exceeded bool
+	)
+	for _, b := range backups {
+		if !exceeded {
+			exceeded = (f.rotate.MaxBackups > 0 && count >= f.rotate.MaxBackups) ||
+				(f.rotate.MaxTotalSize > 0 && totalSize+b.size > f.rotate.MaxTotalSize)
+		}
+		if exceeded || (f.rotate.MaxDays > 0 && b.date.Add(period).Before(deadline)) {
+			if err = os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				f.reportError(nil, fmt.Errorf("remove outdated file '%s': %v", b.path, err))
+			}
+			continue
+		}
+		count++
+		totalSize += b.size
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_file_parseRotatedName(t *testing.T) {
+	Convey("Parse name of rotated file", t, func() {
+		f := &file{filename: "test/app.log"}
+
+		date, seq, ok := f.parseRotatedName("app.log.2017-03-05")
+		So(ok, ShouldBeTrue)
+		So(date.Format(SIMPLE_DATE_FORMAT), ShouldEqual, "2017-03-05")
+		So(seq, ShouldEqual, 0)
+
+		_, seq, ok = f.parseRotatedName("app.log.2017-03-05.012.gz")
+		So(ok, ShouldBeTrue)
+		So(seq, ShouldEqual, 12)
+
+		for _, name := range []string{
+			"app.log",
+			"app.log.conf",
+			"app.logger.log",
+			"app.log.2017-03-05.gz.tmp",
+			"app.log.2017-03-05.+1",
+			"app.log.2017-13-05",
+			"other.log.2017-03-05",
+		} {
+			_, _, ok = f.parseRotatedName(name)
+			So(ok, ShouldBeFalse)
+		}
+	})
+}
+
+func Test_file_deleteOutdatedFiles(t *testing.T) {
+	Convey("Delete rotated files exceed retention limits", t, func() {
+		dir := "test/retention"
+		os.RemoveAll(dir)
+		So(os.MkdirAll(dir, os.ModePerm), ShouldBeNil)
+
+		today := time.Now()
+		names := []string{
+			"app.log",
+			"app.log.conf",
+			"app.logger.log",
+			"app.log." + today.Format(SIMPLE_DATE_FORMAT) + ".001",
+			"app.log." + today.Format(SIMPLE_DATE_FORMAT),
+			"app.log." + today.AddDate(0, 0, -1).Format(SIMPLE_DATE_FORMAT) + ".gz",
+			"app.log." + today.AddDate(0, 0, -5).Format(SIMPLE_DATE_FORMAT),
+		}
+		for _, name := range names {
+			So(ioutil.WriteFile(filepath.Join(dir, name), []byte("0123456789"), os.ModePerm), ShouldBeNil)
+		}
+		// Modification time should not matter.
+		old := today.AddDate(-1, 0, 0)
+		So(os.Chtimes(filepath.Join(dir, names[3]), old, old), ShouldBeNil)
+
+		remaining := func() []string {
+			infos, err := ioutil.ReadDir(dir)
+			So(err, ShouldBeNil)
+			var list []string
+			for _, fi := range infos {
+				list = append(list, fi.Name())
+			}
+			sort.Strings(list)
+			return list
+		}
+		f := &file{filename: filepath.Join(dir, "app.log")}
+
+		Convey("By days", func() {
+			f.rotate.MaxDays = 3
+			f.deleteOutdatedFiles()
+			So(strings.Join(remaining(), ","), ShouldNotContainSubstring, names[6])
+			So(len(remaining()), ShouldEqual, 6)
+		})
+
+		Convey("By number of backups", func() {
+			f.rotate.MaxBackups = 2
+			f.deleteOutdatedFiles()
+			list := remaining()
+			So(len(list), ShouldEqual, 5)
+			So(list, ShouldContain, names[3])
+			So(list, ShouldContain, names[4])
+		})
+
+		Convey("By total size", func() {
+			f.rotate.MaxTotalSize = 25
+			f.deleteOutdatedFiles()
+			list := remaining()
+			So(len(list), ShouldEqual, 5)
+			So(list, ShouldContain, names[3])
+			So(list, ShouldContain, names[4])
+		})
+
+		Convey("No limit", func() {
+			f.deleteOutdatedFiles()
+			So(len(remaining()), ShouldEqual, len(names))
+		})
+	})
+}
@@ -0,0 +1,267 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//分割文件名中时间的格式
+const (
+	rotateHourFormat   = "2006-01-02-15"
+	rotateMinuteFormat = "2006-01-02-15-04"
+)
+
+// rotateLayouts are all layouts of time in names of rotated files, longest first.
+var rotateLayouts = []string{rotateMinuteFormat, rotateHourFormat, SIMPLE_DATE_FORMAT}
+
+//按时间分文件的配置
+// initInterval validates and sets up time-based rotation, Daily is same as 24 hours of interval.
+func (f *file) initInterval(cfg FileRotationConfig) (err error) {
+	f.location = time.Local
+	if len(cfg.TimeZone) > 0 {
+		if f.location, err = time.LoadLocation(cfg.TimeZone); err != nil {
+			return fmt.Errorf("LoadLocation '%s': %v", cfg.TimeZone, err)
+		}
+	}
+
+	f.interval = cfg.Interval
+	if f.interval == 0 && cfg.Daily {
+		f.interval = 24 * time.Hour
+	}
+	if f.interval != 0 &&
+		(f.interval < time.Minute || f.interval > 24*time.Hour || f.interval%time.Minute != 0) {
+		return errors.New("rotation interval must be whole minutes between 1 minute and 24 hours")
+	}
+	if cfg.Offset < 0 || cfg.Offset >= 24*time.Hour {
+		return errors.New("rotation offset must be between 0 and 24 hours")
+	}
+	return nil
+}
+
+//时区
+func (f *file) timeLocation() *time.Location {
+	if f.location == nil {
+		return time.Local
+	}
+	return f.location
+}
+
+//分割文件名中时间的格式
+// timeLayout returns layout of time in names of rotated files, which has resolution of
+// day, hour or minute depending on rotation interval and offset.
+func (f *file) timeLayout() string {
+	switch {
+	case f.interval == 0 || f.interval%(24*time.Hour) == 0:
+		return SIMPLE_DATE_FORMAT
+	case f.interval%time.Hour == 0 && f.rotate.Offset%time.Hour == 0:
+		return rotateHourFormat
+	}
+	return rotateMinuteFormat
+}
+
+//包含给定时间的周期
+// period returns start and end of the rotation period contains t. Periods are aligned to
+// midnight plus offset in time zone of the logger, and restart on the next day when
+// interval does not divide a day.
+func (f *file) period(t time.Time) (start, end time.Time) {
+	loc := f.timeLocation()
+	t = t.In(loc)
+	y, m, d := t.Date()
+	base := time.Date(y, m, d, 0, 0, 0, 0, loc).Add(f.rotate.Offset)
+	if base.After(t) {
+		d--
+		base = time.Date(y, m, d, 0, 0, 0, 0, loc).Add(f.rotate.Offset)
+	}
+
+	start = base.Add(t.Sub(base) / f.interval * f.interval)
+	end = start.Add(f.interval)
+	if next := time.Date(y, m, d+1, 0, 0, 0, 0, loc).Add(f.rotate.Offset); end.After(next) {
+		end = next
+	}
+	return start, end
+}
+
+//设置当前周期
+// setPeriod records the rotation period contains t if time-based rotation is enabled.
+func (f *file) setPeriod(t time.Time) {
+	if f.interval > 0 {
+		f.periodStart, f.periodEnd = f.period(t)
+	}
+}
+
+//分割文件名的模板
+// rotateNaming is a parsed template of rotated file names, which is
+// prefix + {date} + middle + {seq} + suffix. The sep is the separator at the end of middle,
+// which is omitted along with {seq} when sequence number is 0.
+type rotateNaming struct {
+	prefix string
+	middle string
+	sep    string
+	suffix string
+}
+
+//解析模板
+// parseNameTemplate parses a template of rotated file names contains {date} and optionally
+// {seq} after it. When {seq} is absent, it goes before the extension with separator ".".
+func parseNameTemplate(tmpl string) (*rotateNaming, error) {
+	if strings.ContainsAny(tmpl, `/\`) {
+		return nil, fmt.Errorf("name template '%s' cannot contain path separator", tmpl)
+	}
+	if strings.Count(tmpl, "{date}") != 1 || strings.Count(tmpl, "{seq}") > 1 {
+		return nil, fmt.Errorf("name template '%s' must contain one {date} and at most one {seq}", tmpl)
+	}
+
+	i := strings.Index(tmpl, "{date}")
+	n := &rotateNaming{prefix: tmpl[:i]}
+	rest := tmpl[i+len("{date}"):]
+
+	j := strings.Index(rest, "{seq}")
+	switch {
+	case j >= 0:
+		n.middle, n.suffix = rest[:j], rest[j+len("{seq}"):]
+	case strings.Contains(n.prefix, "{seq}"):
+		return nil, fmt.Errorf("name template '%s' must have {seq} after {date}", tmpl)
+	default:
+		// Keep the extension last, e.g. "app-{date}.log" to "app-{date}.{seq}.log".
+		ext := filepath.Ext(rest)
+		n.middle, n.suffix = rest[:len(rest)-len(ext)]+".", ext
+	}
+	if len(n.middle) > 0 && strings.ContainsAny(n.middle[len(n.middle)-1:], ".-_") {
+		n.sep = n.middle[len(n.middle)-1:]
+	}
+	return n, nil
+}
+
+//文件名
+// name returns file name with given date and sequence number.
+func (n *rotateNaming) name(date string, seq int) string {
+	if seq == 0 {
+		return n.prefix + date + n.middle[:len(n.middle)-len(n.sep)] + n.suffix
+	}
+	return n.prefix + date + n.middle + fmt.Sprintf("%03d", seq) + n.suffix
+}
+
+//拆分文件名
+// split returns date and sequence number in given file name, compressed suffixes are
+// allowed. The ok is false if the name does not follow the template.
+func (n *rotateNaming) split(name string) (date string, seq int, ok bool) {
+	for _, suffix := range compressedSuffixes() {
+		if strings.HasSuffix(name, suffix) {
+			name = name[:len(name)-len(suffix)]
+			break
+		}
+	}
+	if len(name) < len(n.prefix)+len(n.suffix) ||
+		!strings.HasPrefix(name, n.prefix) || !strings.HasSuffix(name, n.suffix) {
+		return "", 0, false
+	}
+	rest := name[len(n.prefix) : len(name)-len(n.suffix)]
+
+	for _, layout := range rotateLayouts {
+		if len(rest) < len(layout) {
+			continue
+		}
+		if _, err := time.Parse(layout, rest[:len(layout)]); err != nil {
+			continue
+		}
+		date, tail := rest[:len(layout)], rest[len(layout):]
+
+		if tail == n.middle[:len(n.middle)-len(n.sep)] {
+			return date, 0, true
+		}
+		if strings.HasPrefix(tail, n.middle) && isDigits(tail[len(n.middle):]) {
+			if seq, err := strconv.Atoi(tail[len(n.middle):]); err == nil && seq > 0 {
+				return date, seq, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+//分割文件名的模板
+// naming returns the template of rotated file names, which is "<base name>.{date}.{seq}"
+// by default, e.g. "clog.log.2017-03-05" and "clog.log.2017-03-05.001". When Filename
+// contains {date}, its base name is the default, e.g. "app-2017-03-05.001.log".
+func (f *file) naming() (*rotateNaming, error) {
+	tmpl := f.rotate.NameTemplate
+	switch {
+	case len(tmpl) > 0:
+	case len(f.pattern) > 0:
+		tmpl = filepath.Base(f.pattern)
+	default:
+		tmpl = filepath.Base(f.filename) + ".{date}.{seq}"
+	}
+	return parseNameTemplate(tmpl)
+}
+
+//带日期的文件名
+// initPattern validates Filename contains {date} and sets up the name of current file,
+// such files are rotated by period, daily if no interval is set.
+func (f *file) initPattern(pattern string) error {
+	if strings.Contains(filepath.Dir(pattern), "{date}") {
+		return fmt.Errorf("filename '%s' can only have {date} in base name", pattern)
+	}
+	if _, err := parseNameTemplate(filepath.Base(pattern)); err != nil {
+		return err
+	}
+	f.pattern = pattern
+	f.rotate.Rotate = true
+	if f.interval == 0 {
+		f.interval = 24 * time.Hour
+	}
+	f.setPeriod(time.Now())
+	f.filename = f.datedFilename()
+	return nil
+}
+
+//当前周期的文件名
+// datedFilename returns name of current file with start time of current period.
+func (f *file) datedFilename() string {
+	// Pattern has been validated by initPattern.
+	naming, _ := parseNameTemplate(filepath.Base(f.pattern))
+	date := f.periodStart.In(f.timeLocation()).Format(f.timeLayout())
+	return filepath.Join(filepath.Dir(f.pattern), naming.name(date, 0))
+}
+
+//获取rotate文件的名字
+// rotateFilename returns next available rotate filename with given date,
+// the sequence number is one greater than any existing file with same date.
+func (f *file) rotateFilename(date string) (string, error) {
+	naming, err := f.naming()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(f.filename)
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("ReadDir '%s': %v", dir, err)
+	}
+	seq := 0
+	for _, fi := range infos {
+		d, s, ok := naming.split(fi.Name())
+		if ok && d == date && s >= seq {
+			seq = s + 1
+		}
+	}
+	return filepath.Join(dir, naming.name(date, seq)), nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_file_initInterval(t *testing.T) {
+	Convey("Validate time-based rotation", t, func() {
+		f := &file{}
+		So(f.initInterval(FileRotationConfig{Daily: true}), ShouldBeNil)
+		So(f.interval, ShouldEqual, 24*time.Hour)
+		So(f.location, ShouldEqual, time.Local)
+
+		So(f.initInterval(FileRotationConfig{Daily: true, Interval: time.Hour, TimeZone: "UTC"}), ShouldBeNil)
+		So(f.interval, ShouldEqual, time.Hour)
+		So(f.location, ShouldEqual, time.UTC)
+
+		So(f.initInterval(FileRotationConfig{Interval: time.Second}), ShouldNotBeNil)
+		So(f.initInterval(FileRotationConfig{Interval: 90 * time.Second}), ShouldNotBeNil)
+		So(f.initInterval(FileRotationConfig{Interval: 48 * time.Hour}), ShouldNotBeNil)
+		So(f.initInterval(FileRotationConfig{Offset: 24 * time.Hour}), ShouldNotBeNil)
+		So(f.initInterval(FileRotationConfig{TimeZone: "Mars/Olympus"}), ShouldNotBeNil)
+	})
+}
+
+func Test_file_period(t *testing.T) {
+	Convey("Get rotation period and name layout", t, func() {
+		at := func(s string) time.Time {
+			t, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+			So(err, ShouldBeNil)
+			return t
+		}
+		newFile := func(cfg FileRotationConfig) *file {
+			cfg.TimeZone = "UTC"
+			f := &file{rotate: cfg}
+			So(f.initInterval(cfg), ShouldBeNil)
+			return f
+		}
+
+		Convey("Hourly", func() {
+			f := newFile(FileRotationConfig{Interval: time.Hour})
+			start, end := f.period(at("2017-03-05 13:25"))
+			So(start, ShouldResemble, at("2017-03-05 13:00"))
+			So(end, ShouldResemble, at("2017-03-05 14:00"))
+			So(f.timeLayout(), ShouldEqual, "2006-01-02-15")
+		})
+
+		Convey("Every 7 minutes restarts at midnight", func() {
+			f := newFile(FileRotationConfig{Interval: 7 * time.Minute})
+			start, end := f.period(at("2017-03-05 23:59"))
+			So(start, ShouldResemble, at("2017-03-05 23:55"))
+			So(end, ShouldResemble, at("2017-03-06 00:00"))
+			So(f.timeLayout(), ShouldEqual, "2006-01-02-15-04")
+		})
+
+		Convey("Daily at fixed time", func() {
+			f := newFile(FileRotationConfig{Daily: true, Offset: 2 * time.Hour})
+			start, end := f.period(at("2017-03-05 01:00"))
+			So(start, ShouldResemble, at("2017-03-04 02:00"))
+			So(end, ShouldResemble, at("2017-03-05 02:00"))
+			So(f.timeLayout(), ShouldEqual, SIMPLE_DATE_FORMAT)
+		})
+
+		Convey("In time zone of the logger", func() {
+			f := newFile(FileRotationConfig{Daily: true})
+			start, _ := f.period(time.Date(2017, 3, 5, 1, 0, 0, 0, time.FixedZone("UTC+8", 8*3600)))
+			So(start, ShouldResemble, at("2017-03-04 00:00"))
+		})
+	})
+}
+
+func Test_file_Interval(t *testing.T) {
+	Convey("Rotate file when a period ends", t, func() {
+		os.RemoveAll("test/interval")
+
+		w, err := NewFileWriter("test/interval/interval.log", FileRotationConfig{
+			Rotate:   true,
+			Interval: time.Hour,
+			TimeZone: "UTC",
+		})
+		So(err, ShouldBeNil)
+		f := w.(*file)
+		start := f.periodStart
+		So(f.periodEnd.Sub(start), ShouldEqual, time.Hour)
+
+		_, err = w.Write([]byte("old period"))
+		So(err, ShouldBeNil)
+
+		// Pretend the period has ended.
+		f.periodEnd = time.Now()
+		_, err = w.Write([]byte("new period"))
+		So(err, ShouldBeNil)
+
+		rotated := "test/interval/interval.log." + start.Format("2006-01-02-15")
+		data, err := ioutil.ReadFile(rotated)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "old period")
+		So(string(data), ShouldNotContainSubstring, "new period")
+
+		data, err = ioutil.ReadFile("test/interval/interval.log")
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, "new period")
+
+		_, _, ok := f.parseRotatedName("interval.log." + start.Format("2006-01-02-15") + ".001")
+		So(ok, ShouldBeTrue)
+	})
+}
+
+func Test_parseNameTemplate(t *testing.T) {
+	Convey("Parse template of rotated file names", t, func() {
+		Convey("With sequence number", func() {
+			n, err := parseNameTemplate("app-{date}-{seq}.log")
+			So(err, ShouldBeNil)
+			So(n.name("2017-03-05", 0), ShouldEqual, "app-2017-03-05.log")
+			So(n.name("2017-03-05", 1), ShouldEqual, "app-2017-03-05-001.log")
+			So(n.name("2017-03-05", 1000), ShouldEqual, "app-2017-03-05-1000.log")
+
+			date, seq, ok := n.split("app-2017-03-05-1000.log.gz")
+			So(ok, ShouldBeTrue)
+			So(date, ShouldEqual, "2017-03-05")
+			So(seq, ShouldEqual, 1000)
+
+			date, seq, ok = n.split("app-2017-03-05-13.log")
+			So(ok, ShouldBeTrue)
+			So(date, ShouldEqual, "2017-03-05-13")
+			So(seq, ShouldEqual, 0)
+
+			for _, name := range []string{"app.log", "app-2017-03-05-.log", "app-2017-03-05-000.log", "app-2017-03-05.txt"} {
+				_, _, ok = n.split(name)
+				So(ok, ShouldBeFalse)
+			}
+		})
+
+		Convey("Without sequence number", func() {
+			n, err := parseNameTemplate("app-{date}.log")
+			So(err, ShouldBeNil)
+			So(n.name("2017-03-05", 0), ShouldEqual, "app-2017-03-05.log")
+			So(n.name("2017-03-05", 1), ShouldEqual, "app-2017-03-05.001.log")
+		})
+
+		Convey("Invalid templates", func() {
+			for _, tmpl := range []string{"app.log", "{date}-{date}", "{seq}-{date}", "logs/{date}", "{date}{seq}{seq}"} {
+				_, err := parseNameTemplate(tmpl)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func Test_file_NameTemplate(t *testing.T) {
+	Convey("Rotate file with name template", t, func() {
+		os.RemoveAll("test/template")
+
+		_, err := NewFileWriter("test/template/app.log", FileRotationConfig{
+			NameTemplate: "{date}/app.log",
+		})
+		So(err, ShouldNotBeNil)
+
+		w, err := NewFileWriter("test/template/app.log", FileRotationConfig{
+			Rotate:       true,
+			MaxLines:     1,
+			NameTemplate: "app-{date}-{seq}.log",
+		})
+		So(err, ShouldBeNil)
+		f := w.(*file)
+		date := time.Now().Format(SIMPLE_DATE_FORMAT)
+
+		// Pretend there are many rotated files already.
+		So(ioutil.WriteFile(filepath.Join("test/template", "app-"+date+".log"), nil, os.ModePerm), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join("test/template", "app-"+date+"-999.log"), nil, os.ModePerm), ShouldBeNil)
+
+		_, err = w.Write([]byte("first line\n"))
+		So(err, ShouldBeNil)
+		_, err = w.Write([]byte("second line\n"))
+		So(err, ShouldBeNil)
+
+		data, err := ioutil.ReadFile(filepath.Join("test/template", "app-"+date+"-1000.log"))
+		So(err, ShouldBeNil)
+		So(string(data), ShouldEndWith, "first line\n")
+
+		_, seq, ok := f.parseRotatedName("app-" + date + "-1000.log")
+		So(ok, ShouldBeTrue)
+		So(seq, ShouldEqual, 1000)
+	})
+}
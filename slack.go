@@ -21,12 +21,21 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 )
 
+//slackAttachment里的字段
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
 //基本的slackAttachment数据
 type slackAttachment struct {
-	Text  string `json:"text"`
-	Color string `json:"color"`
+	Text   string       `json:"text"`
+	Color  string       `json:"color"`
+	Fields []slackField `json:"fields,omitempty"`
 }
 
 //slackAttachment的slice
@@ -54,6 +63,14 @@ type SlackConfig struct {
 	Level LEVEL //日志的级别
 	// Buffer size defines how many messages can be queued before hangs.
 	BufferSize int64 //buffer的长度
+	// Policy when buffer is full, BLOCK by default.
+	Overflow OVERFLOW //溢出处理
+	// Timeout of BLOCK_TIMEOUT policy, must be positive for it.
+	OverflowTimeout time.Duration //溢出超时
+	// Locate caller for messages of all levels.
+	Caller bool //调用位置
+	// Name identifies the logger among others of same mode, defaults to the mode.
+	Name string //名称
 	// Slack webhook URL.
 	URL string //定义url
 }
@@ -89,6 +106,12 @@ func (s *slack) Init(v interface{}) error {
 		return ErrInvalidLevel{}
 	}
 	s.level = cfg.Level
+	s.caller = cfg.Caller
+	s.name = cfg.Name
+
+	if err := s.setOverflow(cfg.Overflow, cfg.OverflowTimeout); err != nil {
+		return err
+	}
 
 	//url不能为空
 	if len(cfg.URL) == 0 {
@@ -103,6 +126,14 @@ func (s *slack) Init(v interface{}) error {
 	return nil
 }
 
+//从map解析配置
+// DecodeConfig decodes SlackConfig from a generic map.
+func (s *slack) DecodeConfig(m map[string]interface{}) (interface{}, error) {
+	var cfg SlackConfig
+	err := DecodeMap(m, &cfg)
+	return cfg, err
+}
+
 //返回当前的msg lever
 func (s *slack) ExchangeChans(errorChan chan<- error) chan *Message {
 	s.errorChan = errorChan
@@ -115,13 +146,27 @@ func (s *slack) ExchangeChans(errorChan chan<- error) chan *Message {
 
 **/
 func buildSlackPayload(msg *Message) (string, error) {
+	text := msg.Body
+	if msg.Caller != nil {
+		text = "[" + msg.Caller.String() + "] " + text
+	}
+	if len(msg.Module) > 0 {
+		text = "[" + msg.Module + "] " + text
+	}
+	attachment := slackAttachment{
+		Text:  text,
+		Color: slackColors[msg.Level],
+	}
+	//把字段转换为attachment的字段
+	for _, f := range msg.Fields {
+		attachment.Fields = append(attachment.Fields, slackField{
+			Title: f.Key,
+			Value: fmt.Sprint(f.Value),
+			Short: true,
+		})
+	}
 	payload := slackPayload{
-		Attachments: []slackAttachment{
-			{
-				Text:  msg.Body,
-				Color: slackColors[msg.Level],
-			},
-		},
+		Attachments: []slackAttachment{attachment},
 	}
 	p, err := json.Marshal(&payload)
 	if err != nil {
@@ -137,13 +182,14 @@ func (s *slack) write(msg *Message) {
 
 	//消息处理失败
 	if err != nil {
-		s.errorChan <- fmt.Errorf("slack.buildSlackPayload: %v", err)
+		s.reportError(msg, fmt.Errorf("buildSlackPayload: %v", err))
 		return
 	}
 	//发送日志信息
 	resp, err := http.Post(s.url, "application/json", bytes.NewReader([]byte(payload)))
 	if err != nil {
-		s.errorChan <- fmt.Errorf("slack: %v", err)
+		s.reportError(msg, err)
+		return
 	}
 	//关闭请求
 	defer resp.Body.Close()
@@ -151,39 +197,18 @@ func (s *slack) write(msg *Message) {
 	//如果状态码不是200，发送错误
 	if resp.StatusCode/100 != 2 {
 		data, _ := ioutil.ReadAll(resp.Body)
-		s.errorChan <- fmt.Errorf("slack: %s", data)
+		s.reportError(msg, fmt.Errorf("%s", data))
 	}
 }
 
 //开始处理消息
 func (s *slack) Start() {
-LOOP:
-	for {
-		select {
-		case msg := <-s.msgChan:
-			s.write(msg)
-		case <-s.quitChan:
-			break LOOP
-		}
-	}
-
-	for {
-		if len(s.msgChan) == 0 {
-			break
-		}
-
-		s.write(<-s.msgChan)
-	}
-	s.quitChan <- struct{}{} // Notify the cleanup is done.
+	s.run(s.write)
 }
 
 //关闭记录日志
 func (s *slack) Destroy() {
-	s.quitChan <- struct{}{}
-	<-s.quitChan
-
-	close(s.msgChan)
-	close(s.quitChan)
+	s.stop()
 }
 
 //注册stack日志类
@@ -54,5 +54,15 @@ func Test_buildSlackAttchment(t *testing.T) {
 		})
 		So(err, ShouldBeNil)
 		So(payload, ShouldEqual, `{"attachments":[{"text":"test message","color":"#3aa3e3"}]}`)
+
+		Convey("With fields", func() {
+			payload, err := buildSlackPayload(&Message{
+				Level:  WARN,
+				Body:   "test message",
+				Fields: []Field{{"user_id", 42}},
+			})
+			So(err, ShouldBeNil)
+			So(payload, ShouldEqual, `{"attachments":[{"text":"test message","color":"warning","fields":[{"title":"user_id","value":"42","short":true}]}]}`)
+		})
 	})
 }
@@ -0,0 +1,117 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"expvar"
+	"sync/atomic"
+	"time"
+)
+
+//消息处理器的统计，原子操作
+// counters keeps runtime statistics of a receiver, all fields are accessed atomically.
+type counters struct {
+	levels       [FATAL + 1]int64 //每个级别处理的消息数
+	dropped      int64            //丢弃的消息数
+	errors       int64            //写入错误数
+	writeTime    int64            //写入的总时间，纳秒
+	maxWriteTime int64            //最长的写入时间，纳秒
+}
+
+//记录一个处理完的消息
+// processed records a message of given level has been written in d.
+func (c *counters) processed(level LEVEL, d time.Duration) {
+	if isValidLevel(level) {
+		atomic.AddInt64(&c.levels[level], 1)
+	}
+	atomic.AddInt64(&c.writeTime, int64(d))
+	for {
+		max := atomic.LoadInt64(&c.maxWriteTime)
+		if int64(d) <= max || atomic.CompareAndSwapInt64(&c.maxWriteTime, max, int64(d)) {
+			break
+		}
+	}
+}
+
+//消息处理器的统计
+// ReceiverStats is a snapshot of runtime statistics of a logger.
+// Processed counts and write time are only available for loggers embed Adapter.
+type ReceiverStats struct {
+	Mode MODE
+	Name string
+	// Number of processed messages by level name, e.g. "INFO".
+	Processed map[string]int64
+	// Number of messages discarded due to overflow.
+	Dropped int64
+	// Number of errors reported by the logger.
+	Errors int64
+	// Number of messages in buffer and size of buffer.
+	QueueLength   int
+	QueueCapacity int
+	// Total and maximum time spent on writing a message.
+	WriteTime    time.Duration
+	MaxWriteTime time.Duration
+}
+
+//获取统计
+func (r *receiver) stats() ReceiverStats {
+	s := ReceiverStats{
+		Mode:          r.mode,
+		Name:          r.name,
+		Processed:     make(map[string]int64, len(levelNames)),
+		Dropped:       atomic.LoadInt64(&r.counters.dropped),
+		Errors:        atomic.LoadInt64(&r.counters.errors),
+		QueueLength:   len(r.msgChan),
+		QueueCapacity: cap(r.msgChan),
+		WriteTime:     time.Duration(atomic.LoadInt64(&r.counters.writeTime)),
+		MaxWriteTime:  time.Duration(atomic.LoadInt64(&r.counters.maxWriteTime)),
+	}
+	for level, name := range levelNames {
+		s.Processed[name] = atomic.LoadInt64(&r.counters.levels[level])
+	}
+	return s
+}
+
+//所有消息处理器的统计
+// Stats returns statistics of all loggers in the order they are created.
+func (c *Clog) Stats() []ReceiverStats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	stats := make([]ReceiverStats, len(c.receivers))
+	for i := range c.receivers {
+		stats[i] = c.receivers[i].stats()
+	}
+	return stats
+}
+
+// Stats returns statistics of all loggers of default instance.
+func Stats() []ReceiverStats {
+	return std.Stats()
+}
+
+//发布到expvar
+// PublishExpvar publishes statistics of all loggers as an expvar variable with given name,
+// which is shown in /debug/vars. It panics if the name is already in use.
+func (c *Clog) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return c.Stats()
+	}))
+}
+
+// PublishExpvar publishes statistics of all loggers of default instance as an expvar variable.
+func PublishExpvar(name string) {
+	std.PublishExpvar(name)
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_counters_processed(t *testing.T) {
+	Convey("Record processed messages", t, func() {
+		c := new(counters)
+		c.processed(INFO, 2*time.Millisecond)
+		c.processed(INFO, time.Millisecond)
+		c.processed(LEVEL(9), time.Millisecond)
+		So(c.levels[INFO], ShouldEqual, 2)
+		So(c.writeTime, ShouldEqual, 4*time.Millisecond)
+		So(c.maxWriteTime, ShouldEqual, 2*time.Millisecond)
+	})
+}
+
+func Test_Stats(t *testing.T) {
+	Convey("Get statistics of loggers", t, func() {
+		c := NewClog()
+		So(c.New(_MEMORY, memoryConfig{
+			BufferSize: 10,
+		}), ShouldBeNil)
+
+		wg.Add(3)
+		c.Info("1")
+		c.Info("2")
+		c.Warn("3")
+		wg.Wait()
+
+		stats := c.Stats()
+		So(stats, ShouldHaveLength, 1)
+		So(stats[0].Mode, ShouldEqual, _MEMORY)
+		So(stats[0].QueueCapacity, ShouldEqual, 10)
+		So(stats[0].Dropped, ShouldEqual, 0)
+
+		// The message is counted right after written.
+		for i := 0; i < 100 && c.Stats()[0].Processed["WARN"] == 0; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		stats = c.Stats()
+		So(stats[0].Processed["INFO"], ShouldEqual, 2)
+		So(stats[0].Processed["WARN"], ShouldEqual, 1)
+		So(stats[0].Processed["ERROR"], ShouldEqual, 0)
+
+		Convey("Count errors reported by logger", func() {
+			c.receivers[0].errorChan <- errors.New("boom")
+			for i := 0; i < 100 && c.Stats()[0].Errors == 0; i++ {
+				time.Sleep(time.Millisecond)
+			}
+			So(c.Stats()[0].Errors, ShouldEqual, 1)
+		})
+
+		Convey("Publish to expvar", func() {
+			// expvar panics on duplicated names, e.g. when run with -count.
+			name := fmt.Sprintf("clog_test_stats_%d", time.Now().UnixNano())
+			c.PublishExpvar(name)
+			var v []ReceiverStats
+			So(json.Unmarshal([]byte(expvar.Get(name).String()), &v), ShouldBeNil)
+			So(v, ShouldHaveLength, 1)
+			So(v[0].Mode, ShouldEqual, _MEMORY)
+		})
+
+		c.Shutdown()
+	})
+}
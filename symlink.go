@@ -0,0 +1,48 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"os"
+	"path/filepath"
+)
+
+//更新指向当前文件的链接
+// updateSymlink points Symlink to current file if it is set. The link target is relative
+// to directory of the link when possible, and the link is replaced atomically so readers
+// never see it missing.
+func (f *file) updateSymlink() error {
+	link := f.rotate.Symlink
+	if len(link) == 0 {
+		return nil
+	}
+
+	target, err := filepath.Abs(f.filename)
+	if err != nil {
+		return err
+	}
+	if dir, err := filepath.Abs(filepath.Dir(link)); err == nil {
+		if rel, err := filepath.Rel(dir, target); err == nil {
+			target = rel
+		}
+	}
+
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	if err = os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 Unknwon
+//
+// Licensed under the Apache License, Version 2.0 (the "License"): you may
+// not use this file except in compliance with the License. You may obtain
+// a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package clog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_file_Symlink(t *testing.T) {
+	Convey("Keep symlink to current file with date in name", t, func() {
+		dir := "test/symlink"
+		os.RemoveAll(dir)
+
+		_, err := NewFileWriter("test/{date}/app.log", FileRotationConfig{})
+		So(err, ShouldNotBeNil)
+
+		type rotation struct{ oldPath, newPath string }
+		rotations := make(chan rotation, 10)
+		w, err := NewFileWriter(filepath.Join(dir, "app-{date}.log"), FileRotationConfig{
+			TimeZone: "UTC",
+			MaxLines: 1,
+			Symlink:  filepath.Join(dir, "app.log"),
+			OnRotate: func(oldPath, newPath string) {
+				rotations <- rotation{oldPath, newPath}
+			},
+		})
+		So(err, ShouldBeNil)
+		f := w.(*file)
+
+		today := time.Now().UTC().Format(SIMPLE_DATE_FORMAT)
+		yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(SIMPLE_DATE_FORMAT)
+		So(f.filename, ShouldEqual, filepath.Join(dir, "app-"+today+".log"))
+		target, err := os.Readlink(filepath.Join(dir, "app.log"))
+		So(err, ShouldBeNil)
+		So(target, ShouldEqual, "app-"+today+".log")
+
+		Convey("Open new file when period ends", func() {
+			// Pretend current file was opened yesterday.
+			f.file.Close()
+			f.periodEnd = f.periodStart
+			f.periodStart = f.periodStart.AddDate(0, 0, -1)
+			f.filename = f.datedFilename()
+			So(f.initFile(), ShouldBeNil)
+			So(f.updateSymlink(), ShouldBeNil)
+
+			f.rotate.MaxLines = 0
+			_, err = w.Write([]byte("new day"))
+			So(err, ShouldBeNil)
+			f.background.Wait()
+
+			So(<-rotations, ShouldResemble, rotation{
+				filepath.Join(dir, "app-"+yesterday+".log"),
+				filepath.Join(dir, "app-"+today+".log"),
+			})
+			data, err := ioutil.ReadFile(filepath.Join(dir, "app.log"))
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEndWith, "new day\n")
+		})
+
+		Convey("Rename file when it is too large", func() {
+			_, err = w.Write([]byte("first line"))
+			So(err, ShouldBeNil)
+			f.background.Wait()
+
+			So(<-rotations, ShouldResemble, rotation{
+				filepath.Join(dir, "app-"+today+".001.log"),
+				filepath.Join(dir, "app-"+today+".log"),
+			})
+			data, err := ioutil.ReadFile(filepath.Join(dir, "app-"+today+".001.log"))
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEndWith, "first line\n")
+		})
+	})
+}
+
+func Test_file_OnRotate(t *testing.T) {
+	Convey("Destroy does not wait for OnRotate", t, func() {
+		os.RemoveAll("test/onrotate")
+
+		called := make(chan string, 1)
+		release := make(chan struct{})
+		c := NewClog()
+		So(c.New(FILE, FileConfig{
+			Filename: "test/onrotate/app.log",
+			FileRotationConfig: FileRotationConfig{
+				Rotate:   true,
+				MaxLines: 1,
+				OnRotate: func(oldPath, newPath string) {
+					called <- oldPath
+					<-release
+				},
+			},
+		}), ShouldBeNil)
+
+		c.Info("first message")
+		So(<-called, ShouldStartWith, filepath.Join("test/onrotate", "app.log."))
+
+		done := make(chan struct{})
+		go func() {
+			c.Shutdown()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			So("shutdown is blocked by OnRotate", ShouldBeEmpty)
+		}
+		close(release)
+	})
+}